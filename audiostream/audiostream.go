@@ -0,0 +1,263 @@
+// Package audiostream is a server for the audio of calls connected to a
+// websocket endpoint. Vonage sends a JSON message with the endpoint's
+// headers, then 16-bit linear PCM in 20ms frames; audio written back is
+// played into the call
+package audiostream
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FrameDuration is the length of audio in each binary message
+const FrameDuration = 20 * time.Millisecond
+
+// maxBufferedAudio is how much inbound audio is kept when it isn't being
+// read fast enough, the oldest audio is dropped after this
+const maxBufferedAudio = 10 * time.Second
+
+// Metadata is the first message on the connection. Headers holds the
+// headers set on the websocket endpoint
+type Metadata struct {
+	Event       string
+	ContentType string
+	SampleRate  int
+	Headers     map[string]interface{}
+}
+
+// DTMF is a key pressed by the caller
+type DTMF struct {
+	Digit    string `json:"digit"`
+	Duration int    `json:"duration"`
+}
+
+// Message is a text message other than DTMF, with its raw JSON
+type Message struct {
+	Event string
+	Raw   []byte
+}
+
+// Server accepts websocket connections from Vonage. Handle runs for each
+// call and the connection is closed when it returns. OnDTMF and
+// OnMessage are called from the goroutine reading the connection
+type Server struct {
+	Handle    func(*Conn)
+	OnDTMF    func(*Conn, DTMF)
+	OnMessage func(*Conn, Message)
+}
+
+// ServeHTTP upgrades the request, reads the metadata and runs Handle
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.close(nil)
+
+	opcode, data, err := ws.readMessage()
+	if err != nil || opcode != opText {
+		return
+	}
+	metadata, err := parseMetadata(data)
+	if err != nil {
+		return
+	}
+
+	conn := newConn(ws, metadata)
+	go conn.readLoop(s)
+	if s.Handle != nil {
+		s.Handle(conn)
+	}
+	conn.Close()
+}
+
+func parseMetadata(data []byte) (Metadata, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Metadata{}, err
+	}
+
+	metadata := Metadata{Headers: map[string]interface{}{}}
+	for key, value := range fields {
+		switch key {
+		case "event":
+			metadata.Event, _ = value.(string)
+		case "content-type":
+			metadata.ContentType, _ = value.(string)
+		default:
+			metadata.Headers[key] = value
+		}
+	}
+
+	metadata.SampleRate = 16000
+	for _, param := range strings.Split(metadata.ContentType, ";") {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "rate=") {
+			rate, err := strconv.Atoi(strings.TrimPrefix(param, "rate="))
+			if err != nil || (rate != 8000 && rate != 16000) {
+				return metadata, errors.New("Unsupported sample rate in " + metadata.ContentType)
+			}
+			metadata.SampleRate = rate
+		}
+	}
+	return metadata, nil
+}
+
+// Conn is one call's audio. Read it as an io.Reader of inbound PCM and
+// write PCM to it to play audio into the call
+type Conn struct {
+	Metadata Metadata
+	ws       *websocket
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	inbound []byte
+	err     error
+
+	writeMu  sync.Mutex
+	pending  []byte
+	nextSend time.Time
+	sleep    func(time.Duration)
+	now      func() time.Time
+}
+
+func newConn(ws *websocket, metadata Metadata) *Conn {
+	c := &Conn{Metadata: metadata, ws: ws, sleep: time.Sleep, now: time.Now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// FrameSize is the number of bytes in 20ms of audio, 320 at 8kHz and 640
+// at 16kHz
+func (c *Conn) FrameSize() int {
+	return c.Metadata.SampleRate / 50 * 2
+}
+
+func (c *Conn) readLoop(s *Server) {
+	for {
+		opcode, data, err := c.ws.readMessage()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		if opcode == opBinary {
+			c.mu.Lock()
+			c.inbound = append(c.inbound, data...)
+			limit := int(maxBufferedAudio/FrameDuration) * c.FrameSize()
+			if len(c.inbound) > limit {
+				c.inbound = c.inbound[len(c.inbound)-limit:]
+			}
+			c.mu.Unlock()
+			c.cond.Broadcast()
+			continue
+		}
+
+		var event struct {
+			Event string `json:"event"`
+		}
+		json.Unmarshal(data, &event)
+		if event.Event == "websocket:dtmf" {
+			var dtmf DTMF
+			if json.Unmarshal(data, &dtmf) == nil && s.OnDTMF != nil {
+				s.OnDTMF(c, dtmf)
+			}
+		} else if s.OnMessage != nil {
+			s.OnMessage(c, Message{Event: event.Event, Raw: data})
+		}
+	}
+}
+
+func (c *Conn) fail(err error) {
+	if err == errConnectionClosed {
+		err = io.EOF
+	}
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// Read reads inbound audio, blocking until some arrives. It returns
+// io.EOF once the connection has closed and the audio has all been read
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.inbound) == 0 && c.err == nil {
+		c.cond.Wait()
+	}
+	if len(c.inbound) == 0 {
+		return 0, c.err
+	}
+	n := copy(p, c.inbound)
+	c.inbound = c.inbound[n:]
+	return n, nil
+}
+
+// Write queues audio to play into the call. Whole frames are sent straight
+// away, paced to real time so Vonage's buffer doesn't overflow; the rest
+// waits for more audio or for Flush
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.pending = append(c.pending, p...)
+	size := c.FrameSize()
+	for len(c.pending) >= size {
+		if err := c.sendFrame(c.pending[:size]); err != nil {
+			return 0, err
+		}
+		c.pending = c.pending[size:]
+	}
+	return len(p), nil
+}
+
+// Flush sends any queued audio, padded with silence to a whole frame
+func (c *Conn) Flush() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if len(c.pending) == 0 {
+		return nil
+	}
+	frame := make([]byte, c.FrameSize())
+	copy(frame, c.pending)
+	c.pending = nil
+	return c.sendFrame(frame)
+}
+
+// sendFrame waits until the frame is due, so audio is sent no faster
+// than it plays. After a pause in writing it starts again from now
+func (c *Conn) sendFrame(frame []byte) error {
+	now := c.now()
+	if c.nextSend.Before(now) {
+		c.nextSend = now
+	}
+	if wait := c.nextSend.Sub(now); wait > 0 {
+		c.sleep(wait)
+	}
+	c.nextSend = c.nextSend.Add(FrameDuration)
+	return c.ws.writeFrame(opBinary, frame)
+}
+
+// WriteMessage sends a JSON text message to Vonage
+func (c *Conn) WriteMessage(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.ws.writeFrame(opText, data)
+}
+
+// Close ends the connection, which hangs up the websocket leg
+func (c *Conn) Close() error {
+	c.fail(errConnectionClosed)
+	return c.ws.close([]byte{0x03, 0xE8})
+}
@@ -0,0 +1,175 @@
+package audiostream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient plays the part of Vonage, sending masked frames
+type testClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, server *httptest.Server) *testClient {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %s", err)
+	}
+	request := "GET /socket HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	conn.Write([]byte(request))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Handshake failed: %s", err)
+	}
+	if resp.StatusCode != 101 || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected handshake response: %d %v", resp.StatusCode, resp.Header)
+	}
+	return &testClient{conn: conn, reader: reader}
+}
+
+func (c *testClient) send(opcode byte, payload []byte) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126, byte(len(payload)>>8), byte(len(payload)))
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+func (c *testClient) receive() (byte, []byte) {
+	var header [2]byte
+	io.ReadFull(c.reader, header[:])
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		io.ReadFull(c.reader, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(c.reader, payload)
+	return header[0] & 0x0F, payload
+}
+
+func TestAudioStreamServer(t *testing.T) {
+	metadata := make(chan Metadata, 1)
+	received := make(chan []byte, 1)
+	digits := make(chan string, 1)
+
+	server := httptest.NewServer(&Server{
+		Handle: func(c *Conn) {
+			metadata <- c.Metadata
+			audio := make([]byte, 2*c.FrameSize())
+			io.ReadFull(c, audio)
+			received <- audio
+
+			// echo one and a half frames back, the half is padded by Flush
+			c.Write(audio[:c.FrameSize()+c.FrameSize()/2])
+			c.Flush()
+			c.WriteMessage(map[string]string{"event": "done"})
+			ioutil.ReadAll(c)
+		},
+		OnDTMF: func(c *Conn, d DTMF) {
+			digits <- d.Digit
+		},
+	})
+	defer server.Close()
+
+	client := dial(t, server)
+	client.send(opText, []byte(`{"event":"websocket:connected","content-type":"audio/l16;rate=8000","caller":"447700900000"}`))
+
+	m := <-metadata
+	if m.SampleRate != 8000 || m.Headers["caller"] != "447700900000" || m.Event != "websocket:connected" {
+		t.Errorf("Unexpected metadata: %+v", m)
+	}
+
+	frame := bytes.Repeat([]byte{1}, 320)
+	client.send(opBinary, frame)
+	client.send(opPing, []byte("hi"))
+	client.send(opText, []byte(`{"event":"websocket:dtmf","digit":"5","duration":260}`))
+	client.send(opBinary, frame)
+
+	if audio := <-received; len(audio) != 640 || audio[639] != 1 {
+		t.Errorf("Unexpected inbound audio: %d bytes", len(audio))
+	}
+	if digit := <-digits; digit != "5" {
+		t.Errorf("Unexpected DTMF: %s", digit)
+	}
+
+	opcode, payload := client.receive()
+	if opcode != opPong || string(payload) != "hi" {
+		t.Errorf("Ping should be answered: %d %q", opcode, payload)
+	}
+	_, first := client.receive()
+	_, second := client.receive()
+	if len(first) != 320 || len(second) != 320 || second[159] != 1 || second[160] != 0 {
+		t.Errorf("Outbound audio should be sent in whole frames: %d %d", len(first), len(second))
+	}
+	opcode, payload = client.receive()
+	if opcode != opText || string(payload) != `{"event":"done"}` {
+		t.Errorf("Unexpected text message: %q", payload)
+	}
+
+	client.send(opClose, []byte{0x03, 0xE8})
+	opcode, _ = client.receive()
+	if opcode != opClose {
+		t.Errorf("Close should be answered, got opcode %d", opcode)
+	}
+}
+
+func TestAudioStreamPacing(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var waited time.Duration
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go ioutil.ReadAll(clientConn)
+
+	c := newConn(&websocket{conn: serverConn}, Metadata{SampleRate: 16000})
+	c.now = func() time.Time { return now }
+	c.sleep = func(d time.Duration) { waited += d; now = now.Add(d) }
+
+	c.Write(make([]byte, 640*3))
+	if waited != 40*time.Millisecond {
+		t.Errorf("Three frames should be paced 20ms apart, waited %s", waited)
+	}
+
+	// after a pause the pacing starts again from now
+	now = now.Add(time.Second)
+	waited = 0
+	c.Write(make([]byte, 640))
+	if waited != 0 {
+		t.Errorf("First frame after a pause should not wait, waited %s", waited)
+	}
+}
+
+func TestAudioStreamRejectsPlainHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&Server{}).ServeHTTP(rec, httptest.NewRequest("GET", "/socket", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Plain HTTP requests should be rejected, got %d", rec.Code)
+	}
+
+	if _, err := parseMetadata([]byte(`{"content-type":"audio/l16;rate=44100"}`)); err == nil {
+		t.Errorf("Unsupported sample rate should be rejected")
+	}
+}
@@ -0,0 +1,241 @@
+package audiostream
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The parts of RFC 6455 that Vonage uses: a server accepting text and
+// binary messages from the client and writing unmasked frames back
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	websocketGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// maxMessageSize stops a bad client using all our memory, audio
+	// messages are 640 bytes at most
+	maxMessageSize = 1 << 20
+
+	// maxControlSize is the longest payload a ping, pong or close frame
+	// can have
+	maxControlSize = 125
+)
+
+var errConnectionClosed = errors.New("Websocket connection closed")
+
+// websocket is one server side connection
+type websocket struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// upgrade completes the websocket handshake and takes over the connection
+func upgrade(w http.ResponseWriter, r *http.Request) (*websocket, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "Expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("Request is not a websocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("Unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("Missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websockets are not supported", http.StatusInternalServerError)
+		return nil, errors.New("ResponseWriter can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGuid))
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocket{conn: conn, reader: rw.Reader}, nil
+}
+
+func headerContains(header http.Header, name string, value string) bool {
+	for _, field := range header[name] {
+		for _, part := range strings.Split(field, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the next text or binary message, answering pings
+// and close frames along the way
+func (ws *websocket) readMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ws.close(payload)
+			return 0, nil, errConnectionClosed
+		case opText, opBinary:
+			if message != nil {
+				return 0, nil, errors.New("Websocket message started before the last one finished")
+			}
+			opcode = op
+			message = payload
+		case opContinuation:
+			if message == nil {
+				return 0, nil, errors.New("Websocket continuation without a message")
+			}
+			if len(message)+len(payload) > maxMessageSize {
+				return 0, nil, errors.New("Websocket message too large")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, errors.New("Unknown websocket opcode")
+		}
+
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (ws *websocket) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	// the RSV bits are only for extensions, and none are agreed
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, errors.New("Websocket frame has reserved bits set")
+	}
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, errors.New("Websocket frame too large")
+	}
+	// control frames can't be split up or have long payloads
+	if opcode&0x8 != 0 && (!fin || length > maxControlSize) {
+		return false, 0, nil, errors.New("Websocket control frame is fragmented or too large")
+	}
+	// clients must mask every frame they send
+	if !masked {
+		return false, 0, nil, errors.New("Websocket frame from the client is not masked")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends one unfragmented frame, it is safe to call from
+// several goroutines
+func (ws *websocket) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closed {
+		return errConnectionClosed
+	}
+
+	frame := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		frame = append(frame, 127)
+		var extended [8]byte
+		binary.BigEndian.PutUint64(extended[:], uint64(length))
+		frame = append(frame, extended[:]...)
+	}
+	frame = append(frame, payload...)
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// close sends a close frame, echoing the status code from the client
+// if there was one, and closes the connection
+func (ws *websocket) close(payload []byte) error {
+	if len(payload) > 2 {
+		payload = payload[:2]
+	}
+	ws.writeFrame(opClose, payload)
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closed {
+		return nil
+	}
+	ws.closed = true
+	return ws.conn.Close()
+}
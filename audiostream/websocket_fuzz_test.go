@@ -0,0 +1,33 @@
+//go:build go1.18
+// +build go1.18
+
+package audiostream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzWebsocketReadMessage(f *testing.F) {
+	f.Add(frame(true, opText, []byte("hello")))
+	f.Add(frame(true, opBinary, bytes.Repeat([]byte("a"), 640)))
+	f.Add(rawFrame(true, opBinary, []byte("hi"), 8, 2))
+	f.Add(append(frame(false, opText, []byte("Hel")), frame(true, opContinuation, []byte("lo"))...))
+	f.Add(append(frame(true, opPing, []byte("ping")), frame(true, opClose, []byte{3, 232})...))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ws := readerSocket(data)
+		for {
+			op, message, err := ws.readMessage()
+			if err != nil {
+				return
+			}
+			if op != opText && op != opBinary {
+				t.Fatalf("Unexpected opcode %d", op)
+			}
+			if len(message) > maxMessageSize {
+				t.Fatalf("Message of %d bytes is over the limit", len(message))
+			}
+		}
+	})
+}
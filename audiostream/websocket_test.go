@@ -0,0 +1,130 @@
+package audiostream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+// rawFrame builds a masked client frame. lengthBytes is 0 for the 7 bit
+// length, or 2 or 8 for the extended lengths, and length is what the
+// header claims whatever the size of the payload
+func rawFrame(fin bool, opcode byte, payload []byte, lengthBytes int, length uint64) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch lengthBytes {
+	case 0:
+		frame = append(frame, 0x80|byte(length))
+	case 2:
+		frame = append(frame, 0x80|126, byte(length>>8), byte(length))
+	default:
+		var extended [8]byte
+		binary.BigEndian.PutUint64(extended[:], length)
+		frame = append(frame, 0x80|127)
+		frame = append(frame, extended[:]...)
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+func frame(fin bool, opcode byte, payload []byte) []byte {
+	switch {
+	case len(payload) < 126:
+		return rawFrame(fin, opcode, payload, 0, uint64(len(payload)))
+	case len(payload) <= 0xFFFF:
+		return rawFrame(fin, opcode, payload, 2, uint64(len(payload)))
+	}
+	return rawFrame(fin, opcode, payload, 8, uint64(len(payload)))
+}
+
+// readerSocket is a websocket that reads data, anything it writes back
+// is thrown away
+func readerSocket(data []byte) *websocket {
+	server, client := net.Pipe()
+	go io.Copy(ioutil.Discard, client)
+	return &websocket{conn: server, reader: bufio.NewReader(bytes.NewReader(data))}
+}
+
+func TestWebsocketFrameLengths(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 70000)
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"7 bit", frame(true, opBinary, []byte("hello")), []byte("hello")},
+		{"16 bit", frame(true, opBinary, long[:126]), long[:126]},
+		{"64 bit", frame(true, opBinary, long), long},
+		{"64 bit for a short payload", rawFrame(true, opBinary, []byte("hi"), 8, 2), []byte("hi")},
+		{"empty", frame(true, opBinary, nil), []byte{}},
+	}
+	for _, test := range tests {
+		ws := readerSocket(test.data)
+		op, message, err := ws.readMessage()
+		if err != nil || op != opBinary || !bytes.Equal(message, test.want) {
+			t.Errorf("%s: unexpected message, %d bytes %v", test.name, len(message), err)
+		}
+	}
+}
+
+func TestWebsocketContinuation(t *testing.T) {
+	var data []byte
+	data = append(data, frame(false, opText, []byte("Hel"))...)
+	data = append(data, frame(true, opPing, []byte("ping"))...)
+	data = append(data, frame(false, opContinuation, []byte("lo "))...)
+	data = append(data, frame(true, opContinuation, []byte("world"))...)
+	data = append(data, frame(true, opBinary, []byte("next"))...)
+
+	ws := readerSocket(data)
+	op, message, err := ws.readMessage()
+	if err != nil || op != opText || string(message) != "Hello world" {
+		t.Errorf("Fragmented message not joined: %d %q %v", op, message, err)
+	}
+	op, message, err = ws.readMessage()
+	if err != nil || op != opBinary || string(message) != "next" {
+		t.Errorf("Message after a fragmented one not read: %d %q %v", op, message, err)
+	}
+	if _, _, err = ws.readMessage(); err != io.EOF {
+		t.Errorf("Expected the end of the data, got %v", err)
+	}
+}
+
+func TestWebsocketFrameLimits(t *testing.T) {
+	half := bytes.Repeat([]byte("a"), maxMessageSize/2+1)
+	tests := []struct {
+		name string
+		data [][]byte
+	}{
+		{"64 bit length with the top bit set", [][]byte{rawFrame(true, opBinary, nil, 8, 1<<63)}},
+		{"64 bit length over the limit", [][]byte{rawFrame(true, opBinary, nil, 8, maxMessageSize+1)}},
+		{"ping over 125 bytes", [][]byte{frame(true, opPing, bytes.Repeat([]byte("p"), 126))}},
+		{"close over 125 bytes", [][]byte{frame(true, opClose, bytes.Repeat([]byte("c"), 126))}},
+		{"fragmented ping", [][]byte{frame(false, opPing, []byte("ping"))}},
+		{"fragmented close", [][]byte{frame(false, opClose, []byte{3, 232})}},
+		{"reserved bits", [][]byte{append([]byte{0xC0 | opText}, frame(true, opText, []byte("hi"))[1:]...)}},
+		{"unmasked", [][]byte{{0x80 | opText, 2, 'h', 'i'}}},
+		{"unknown opcode", [][]byte{frame(true, 0x3, []byte("hi"))}},
+		{"continuation first", [][]byte{frame(true, opContinuation, []byte("hi"))}},
+		{"message inside a message", [][]byte{frame(false, opText, []byte("a")), frame(true, opText, []byte("b"))}},
+		{"continuations over the limit", [][]byte{frame(false, opBinary, half), frame(true, opContinuation, half)}},
+	}
+	for _, test := range tests {
+		// a good message follows, so a bad frame that gets through is noticed
+		data := append(bytes.Join(test.data, nil), frame(true, opText, []byte("after"))...)
+		ws := readerSocket(data)
+		if _, message, err := ws.readMessage(); err == nil || err == errConnectionClosed {
+			t.Errorf("%s: expected an error, got %q %v", test.name, message, err)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+// Package dialer runs outbound calling campaigns. A Campaign dials a list
+// of targets with CreateCall, keeping to a limit on concurrent calls and
+// calls per second, only calling each target during its calling window,
+// and retrying calls that end busy or unanswered. The outcome of each call
+// comes from the completed and unsuccessful event webhooks, so the
+// campaign's event handler must be registered on the app's event URL
+package dialer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	vonage "github.com/vonage/vonage-go-sdk"
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+// Outcome statuses in addition to the final call statuses sent by Vonage
+// (completed, busy, cancelled, failed, rejected, timeout and unanswered)
+const (
+	// StatusMachine is a call that was answered by voicemail
+	StatusMachine = "machine"
+	// StatusError is a call that couldn't be created
+	StatusError = "error"
+	// StatusUnknown is a call that didn't send a final event in time
+	StatusUnknown = "unknown"
+	// StatusNotCalled is a target that the campaign stopped before calling
+	StatusNotCalled = "not_called"
+)
+
+// defaultEventTimeout is how long to wait for a call's final event
+const defaultEventTimeout = 2 * time.Hour
+
+// Target is one number to call. Data fills the {{key}} placeholders in
+// the campaign's NCCO, and Location is the target's timezone for the
+// calling window
+type Target struct {
+	ID       string
+	Number   string
+	Data     map[string]string
+	Location *time.Location
+}
+
+// Window is the time of day calls can be made, as the time since midnight
+// in the target's timezone. Days limits calling to some days of the week,
+// calls can be made every day when it's empty. A zero Window is always open
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+	Days  []time.Weekday
+}
+
+// RetryPolicy says whether to try a target again after a call ends with a
+// given status. The target is retried while it has had no more than
+// Attempts retries, after waiting for Delay
+type RetryPolicy struct {
+	Attempts int
+	Delay    time.Duration
+}
+
+// Attempt is one call made to a target
+type Attempt struct {
+	Uuid      string
+	StartTime time.Time
+	EndTime   time.Time
+	Status    string
+	Error     error
+}
+
+// Outcome is the result for one target. Status is the status of the last
+// attempt, "completed" when the call was answered by a person
+type Outcome struct {
+	Target   Target
+	Status   string
+	Attempts []Attempt
+}
+
+// Report holds the outcome for every target, in the order they were given
+type Report struct {
+	Outcomes []Outcome
+}
+
+// Completed returns the outcomes where the call was answered
+func (r Report) Completed() []Outcome {
+	return r.filter(func(o Outcome) bool { return o.Status == "completed" })
+}
+
+// Failed returns the outcomes where the target was never reached
+func (r Report) Failed() []Outcome {
+	return r.filter(func(o Outcome) bool { return o.Status != "completed" })
+}
+
+func (r Report) filter(keep func(Outcome) bool) []Outcome {
+	var outcomes []Outcome
+	for _, o := range r.Outcomes {
+		if keep(o) {
+			outcomes = append(outcomes, o)
+		}
+	}
+	return outcomes
+}
+
+// Campaign dials a list of targets. Supply either Ncco, a template where
+// text such as "{{name}}" is replaced with the target's Data, NccoFunc to
+// build each call's NCCO, or AnswerUrl
+type Campaign struct {
+	Client           *vonage.VoiceClient
+	From             vonage.CallFrom
+	Ncco             ncco.Ncco
+	NccoFunc         func(Target) (ncco.Ncco, error)
+	AnswerUrl        []string
+	EventUrl         []string
+	MachineDetection string
+	// MaxConcurrent is the most calls in progress at once, default 1
+	MaxConcurrent int
+	// CallsPerSecond limits how fast calls are started, no limit when 0
+	CallsPerSecond float64
+	// Retries is keyed by the status the call ended with, such as "busy"
+	Retries map[string]RetryPolicy
+	Window  Window
+	// Location is the timezone for targets without one, default UTC
+	Location *time.Location
+	// EventTimeout is how long to wait for a call's final event before
+	// giving it the status "unknown", default two hours
+	EventTimeout time.Duration
+	// OnOutcome is called as each target finishes
+	OnOutcome func(Outcome)
+
+	// dialled holds the calls in progress while Run is going, it is nil
+	// between runs
+	mu       sync.Mutex
+	dialled  map[string]bool
+	finished map[string]string
+	machine  map[string]bool
+	wake     chan struct{}
+
+	now func() time.Time
+}
+
+// pending is a call waiting to be made
+type pending struct {
+	index     int
+	notBefore time.Time
+}
+
+// inflight is a call waiting for its final event
+type inflight struct {
+	index    int
+	attempt  Attempt
+	deadline time.Time
+}
+
+// Register adds the campaign's event handler to a Dispatcher for the final
+// call statuses and machine detection events
+func (c *Campaign) Register(d *voicewebhook.Dispatcher) {
+	handle := func(e voicewebhook.Event) (ncco.Ncco, error) {
+		c.HandleEvent(e)
+		return ncco.Ncco{}, nil
+	}
+	for _, status := range []string{"completed", "busy", "cancelled", "failed", "rejected", "timeout", "unanswered", "machine"} {
+		d.Handle(status, handle)
+	}
+}
+
+// HandleEvent records the final status of a call. Only calls made by a
+// campaign that is running are recorded, events for any other calls are
+// ignored
+func (c *Campaign) HandleEvent(e voicewebhook.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event := e.(type) {
+	case voicewebhook.MachineEvent:
+		if event.Status == "machine" && c.dialled[event.Uuid] {
+			c.machine[event.Uuid] = true
+		}
+		return
+	case voicewebhook.CompletedEvent:
+		if !c.dialled[event.Uuid] {
+			return
+		}
+		c.finished[event.Uuid] = event.Status
+	case voicewebhook.UnsuccessfulEvent:
+		if !c.dialled[event.Uuid] {
+			return
+		}
+		c.finished[event.Uuid] = event.Status
+	default:
+		return
+	}
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// start sets up the event maps for a run, and returns the channel that
+// is sent to when a call ends
+func (c *Campaign) start() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.wake == nil {
+		c.wake = make(chan struct{}, 1)
+	}
+	c.dialled = map[string]bool{}
+	c.finished = map[string]string{}
+	c.machine = map[string]bool{}
+	return c.wake
+}
+
+// Run calls every target and returns when each one has an outcome. If ctx
+// is cancelled it stops making calls, the targets not yet called get the
+// status "not_called" and calls in progress "unknown"
+func (c *Campaign) Run(ctx context.Context, targets []Target) (Report, error) {
+	if err := c.validate(); err != nil {
+		return Report{}, err
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	wake := c.start()
+
+	maxConcurrent := c.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	eventTimeout := c.EventTimeout
+	if eventTimeout <= 0 {
+		eventTimeout = defaultEventTimeout
+	}
+	var interval time.Duration
+	if c.CallsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / c.CallsPerSecond)
+	}
+
+	report := Report{Outcomes: make([]Outcome, len(targets))}
+	queue := make([]*pending, len(targets))
+	for i, target := range targets {
+		report.Outcomes[i] = Outcome{Target: target, Status: StatusNotCalled}
+		queue[i] = &pending{index: i}
+	}
+	calls := map[string]*inflight{}
+	var lastDial time.Time
+
+	finish := func(index int, attempt Attempt) {
+		outcome := &report.Outcomes[index]
+		outcome.Attempts = append(outcome.Attempts, attempt)
+		outcome.Status = attempt.Status
+
+		policy, ok := c.Retries[attempt.Status]
+		if ok && len(outcome.Attempts) <= policy.Attempts {
+			queue = append(queue, &pending{index: index, notBefore: attempt.EndTime.Add(policy.Delay)})
+			return
+		}
+		if c.OnOutcome != nil {
+			c.OnOutcome(*outcome)
+		}
+	}
+
+	for len(queue) > 0 || len(calls) > 0 {
+		now := c.now()
+		var wait time.Duration
+		wakeAt := func(t time.Time) {
+			if d := t.Sub(now); d > 0 && (wait == 0 || d < wait) {
+				wait = d
+			}
+		}
+
+		// collect the calls that have ended or timed out
+		for uuid, call := range calls {
+			if status, ok := c.takeFinished(uuid); ok {
+				call.attempt.Status = status
+			} else if now.After(call.deadline) {
+				call.attempt.Status = StatusUnknown
+				c.forget(uuid)
+			} else {
+				wakeAt(call.deadline)
+				continue
+			}
+			call.attempt.EndTime = now
+			delete(calls, uuid)
+			finish(call.index, call.attempt)
+		}
+
+		// start as many calls as the limits allow
+		for len(calls) < maxConcurrent && len(queue) > 0 {
+			next := -1
+			for i, p := range queue {
+				if open := c.nextOpen(now, report.Outcomes[p.index].Target); open.After(p.notBefore) {
+					p.notBefore = open
+				}
+				if !p.notBefore.After(now) {
+					next = i
+					break
+				}
+				wakeAt(p.notBefore)
+			}
+			if next == -1 {
+				break
+			}
+			if interval > 0 && !lastDial.IsZero() && lastDial.Add(interval).After(now) {
+				wakeAt(lastDial.Add(interval))
+				break
+			}
+
+			p := queue[next]
+			queue = append(queue[:next], queue[next+1:]...)
+			lastDial = now
+			attempt, err := c.dial(report.Outcomes[p.index].Target, now)
+			if err != nil {
+				attempt.Status = StatusError
+				attempt.Error = err
+				attempt.EndTime = now
+				finish(p.index, attempt)
+				continue
+			}
+			c.track(attempt.Uuid)
+			calls[attempt.Uuid] = &inflight{index: p.index, attempt: attempt, deadline: now.Add(eventTimeout)}
+		}
+
+		if len(queue) == 0 && len(calls) == 0 {
+			break
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-wake:
+		case <-timeout:
+		case <-ctx.Done():
+			for uuid, call := range calls {
+				call.attempt.Status = StatusUnknown
+				call.attempt.EndTime = c.now()
+				c.forget(uuid)
+				report.Outcomes[call.index].Attempts = append(report.Outcomes[call.index].Attempts, call.attempt)
+				report.Outcomes[call.index].Status = StatusUnknown
+			}
+			c.reset()
+			return report, ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	c.reset()
+	return report, nil
+}
+
+// takeFinished returns the final status for a call if its event has
+// arrived, calls answered by a machine have the status "machine"
+func (c *Campaign) takeFinished(uuid string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.finished[uuid]
+	if ok {
+		if status == "completed" && c.machine[uuid] {
+			status = StatusMachine
+		}
+		c.forgetLocked(uuid)
+	}
+	return status, ok
+}
+
+// track starts recording the events for a call the run has made
+func (c *Campaign) track(uuid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialled[uuid] = true
+}
+
+// forget stops recording the events for a call
+func (c *Campaign) forget(uuid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetLocked(uuid)
+}
+
+// forgetLocked is forget for a caller that holds the lock
+func (c *Campaign) forgetLocked(uuid string) {
+	delete(c.dialled, uuid)
+	delete(c.finished, uuid)
+	delete(c.machine, uuid)
+}
+
+// reset stops recording events once the run has finished
+func (c *Campaign) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialled = nil
+	c.finished = nil
+	c.machine = nil
+}
+
+// dial creates the call for a target
+func (c *Campaign) dial(target Target, now time.Time) (Attempt, error) {
+	attempt := Attempt{StartTime: now}
+	opts := vonage.CreateCallOpts{
+		From:             c.From,
+		To:               vonage.CallTo{Type: "phone", Number: target.Number},
+		AnswerUrl:        c.AnswerUrl,
+		EventUrl:         c.EventUrl,
+		MachineDetection: c.MachineDetection,
+	}
+
+	if c.NccoFunc != nil {
+		n, err := c.NccoFunc(target)
+		if err != nil {
+			return attempt, err
+		}
+		opts.Ncco = n
+	} else if len(c.Ncco.GetActions()) > 0 {
+		n, err := fillTemplate(c.Ncco, target.Data)
+		if err != nil {
+			return attempt, err
+		}
+		opts.Ncco = n
+	}
+
+	result, _, err := c.Client.CreateCall(opts)
+	if err != nil {
+		return attempt, err
+	}
+	attempt.Uuid = result.Uuid
+	return attempt, nil
+}
+
+// nextOpen returns the first time from now that the target can be called
+func (c *Campaign) nextOpen(now time.Time, target Target) time.Time {
+	w := c.Window
+	if w.Start == 0 && w.End == 0 && len(w.Days) == 0 {
+		return now
+	}
+	end := w.End
+	if end == 0 {
+		end = 24 * time.Hour
+	}
+
+	loc := target.Location
+	if loc == nil {
+		loc = c.Location
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	for day := 0; day < 8; day++ {
+		date := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, loc)
+		if !w.allows(date.Weekday()) {
+			continue
+		}
+		opens := atTime(date, w.Start)
+		closes := atTime(date, end)
+		if local.Before(closes) {
+			if local.Before(opens) {
+				return opens
+			}
+			return now
+		}
+	}
+	return now
+}
+
+// allows is true if calls can be made on this day of the week
+func (w Window) allows(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// atTime is the wall clock time on date, which keeps a 9am window at 9am
+// on the days the clocks change
+func atTime(date time.Time, since time.Duration) time.Time {
+	hours := int(since / time.Hour)
+	minutes := int(since % time.Hour / time.Minute)
+	return time.Date(date.Year(), date.Month(), date.Day(), hours, minutes, 0, 0, date.Location())
+}
+
+// fillTemplate replaces {{key}} placeholders anywhere in the NCCO
+func fillTemplate(template ncco.Ncco, data map[string]string) (ncco.Ncco, error) {
+	j, err := json.Marshal(template.GetActions())
+	if err != nil {
+		return ncco.Ncco{}, err
+	}
+	text := string(j)
+	for key, value := range data {
+		// the value goes inside a JSON string, so drop the quotes around it
+		escaped, _ := json.Marshal(value)
+		text = strings.Replace(text, "{{"+key+"}}", string(escaped[1:len(escaped)-1]), -1)
+	}
+	if start := strings.Index(text, "{{"); start != -1 {
+		placeholder := text[start:]
+		if end := strings.Index(placeholder, "}}"); end != -1 {
+			placeholder = placeholder[:end+2]
+		}
+		return ncco.Ncco{}, errors.New("NCCO template has a placeholder with no data: " + placeholder)
+	}
+
+	var n ncco.Ncco
+	err = json.Unmarshal([]byte(text), &n)
+	return n, err
+}
+
+// validate checks the campaign has what it needs to make calls
+func (c *Campaign) validate() error {
+	if c.Client == nil {
+		return errors.New("Campaign needs a Client")
+	}
+	if c.NccoFunc == nil && len(c.Ncco.GetActions()) == 0 && len(c.AnswerUrl) == 0 {
+		return errors.New("Campaign needs an Ncco, NccoFunc or AnswerUrl")
+	}
+	if c.Window.Start < 0 || c.Window.End > 24*time.Hour || (c.Window.End != 0 && c.Window.End <= c.Window.Start) {
+		return errors.New("Window must start before it ends, within one day")
+	}
+	for status, policy := range c.Retries {
+		if policy.Attempts < 0 || policy.Delay < 0 {
+			return errors.New("Retry policy for " + status + " must not be negative")
+		}
+	}
+	return nil
+}
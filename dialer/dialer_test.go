@@ -0,0 +1,299 @@
+package dialer
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	vonage "github.com/vonage/vonage-go-sdk"
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+// fakeCalls answers CreateCall and sends each call's final event from
+// the script for its number, one entry per attempt
+type fakeCalls struct {
+	mu      sync.Mutex
+	count   int
+	live    int
+	maxLive int
+	bodies  []string
+	script  map[string][]string
+}
+
+func (f *fakeCalls) register(c *Campaign) {
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			var call struct {
+				To []struct {
+					Number string `json:"number"`
+				} `json:"to"`
+			}
+			json.Unmarshal(body, &call)
+			number := call.To[0].Number
+
+			f.mu.Lock()
+			f.count++
+			uuid := "call-" + strconv.Itoa(f.count)
+			f.bodies = append(f.bodies, string(body))
+			f.live++
+			if f.live > f.maxLive {
+				f.maxLive = f.live
+			}
+			status := f.script[number][0]
+			f.script[number] = f.script[number][1:]
+			f.mu.Unlock()
+
+			go func() {
+				time.Sleep(5 * time.Millisecond)
+				f.mu.Lock()
+				f.live--
+				f.mu.Unlock()
+				event := voicewebhook.CallEvent{Uuid: uuid, Status: status}
+				switch status {
+				case "completed":
+					c.HandleEvent(voicewebhook.CompletedEvent{CallEvent: event})
+				case "machine":
+					c.HandleEvent(voicewebhook.MachineEvent{CallEvent: event})
+					event.Status = "completed"
+					c.HandleEvent(voicewebhook.CompletedEvent{CallEvent: event})
+				default:
+					c.HandleEvent(voicewebhook.UnsuccessfulEvent{CallEvent: event})
+				}
+			}()
+
+			resp := httpmock.NewStringResponse(201, `{"uuid": "`+uuid+`", "status": "started", "direction": "outbound", "conversation_uuid": "CON-`+uuid+`"}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+}
+
+func newCampaign() *Campaign {
+	auth, _ := vonage.CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	template := ncco.Ncco{}
+	template.AddAction(ncco.TalkAction{Text: "Hello {{name}}, your appointment is at {{time}}"})
+	return &Campaign{
+		Client:   vonage.NewVoiceClient(auth),
+		From:     vonage.CallFrom{Type: "phone", Number: "447700900000"},
+		Ncco:     template,
+		EventUrl: []string{"https://example.com/event"},
+	}
+}
+
+func TestCampaignRetriesAndOutcomes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	campaign := newCampaign()
+	campaign.MaxConcurrent = 2
+	campaign.Retries = map[string]RetryPolicy{"busy": {Attempts: 2, Delay: 10 * time.Millisecond}}
+
+	fake := &fakeCalls{script: map[string][]string{
+		"447700900001": {"busy", "busy", "completed"},
+		"447700900002": {"machine"},
+		"447700900003": {"unanswered"},
+		"447700900004": {"busy", "busy", "busy"},
+	}}
+	fake.register(campaign)
+
+	var reported []string
+	var mu sync.Mutex
+	campaign.OnOutcome = func(o Outcome) {
+		mu.Lock()
+		reported = append(reported, o.Target.ID)
+		mu.Unlock()
+	}
+
+	data := map[string]string{"name": "Jo", "time": "3pm"}
+	targets := []Target{
+		{ID: "a", Number: "447700900001", Data: data},
+		{ID: "b", Number: "447700900002", Data: data},
+		{ID: "c", Number: "447700900003", Data: data},
+		{ID: "d", Number: "447700900004", Data: data},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := campaign.Run(ctx, targets)
+	if err != nil {
+		t.Fatalf("Campaign failed: %s", err)
+	}
+
+	expected := []struct {
+		status   string
+		attempts int
+	}{{"completed", 3}, {StatusMachine, 1}, {"unanswered", 1}, {"busy", 3}}
+	for i, e := range expected {
+		outcome := report.Outcomes[i]
+		if outcome.Status != e.status || len(outcome.Attempts) != e.attempts {
+			t.Errorf("Unexpected outcome for %s: %s after %d attempts", outcome.Target.ID, outcome.Status, len(outcome.Attempts))
+		}
+	}
+	if len(report.Completed()) != 1 || len(report.Failed()) != 3 {
+		t.Errorf("Report should have one completed target")
+	}
+	if len(reported) != 4 {
+		t.Errorf("OnOutcome should be called once for each target: %v", reported)
+	}
+	if fake.maxLive > 2 {
+		t.Errorf("Campaign should have no more than 2 calls at once, had %d", fake.maxLive)
+	}
+	if !strings.Contains(fake.bodies[0], `"text":"Hello Jo, your appointment is at 3pm"`) {
+		t.Errorf("Unexpected JSON format for: campaign NCCO: %s", fake.bodies[0])
+	}
+}
+
+func TestCampaignCallsPerSecond(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	campaign := newCampaign()
+	campaign.Ncco = ncco.Ncco{}
+	campaign.AnswerUrl = []string{"https://example.com/answer"}
+	campaign.MaxConcurrent = 3
+	campaign.CallsPerSecond = 20
+
+	fake := &fakeCalls{script: map[string][]string{
+		"447700900001": {"completed"},
+		"447700900002": {"completed"},
+		"447700900003": {"completed"},
+	}}
+	fake.register(campaign)
+
+	start := time.Now()
+	report, _ := campaign.Run(context.Background(), []Target{
+		{Number: "447700900001"}, {Number: "447700900002"}, {Number: "447700900003"},
+	})
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Three calls at 20 per second should take at least 100ms, took %s", elapsed)
+	}
+	if len(report.Completed()) != 3 {
+		t.Errorf("All the calls should complete: %+v", report.Outcomes)
+	}
+}
+
+func TestCampaignErrorsAndCancel(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	campaign := newCampaign()
+	report, err := campaign.Run(context.Background(), []Target{{ID: "nobody", Data: map[string]string{"name": "Jo", "time": "3pm"}}})
+	if err != nil || report.Outcomes[0].Status != StatusError || report.Outcomes[0].Attempts[0].Error == nil {
+		t.Errorf("Target without a number should have an error outcome: %+v", report.Outcomes)
+	}
+
+	report, _ = campaign.Run(context.Background(), []Target{{Number: "447700900001"}})
+	if report.Outcomes[0].Status != StatusError {
+		t.Errorf("Target missing template data should have an error outcome: %+v", report.Outcomes)
+	}
+
+	// outside the window nothing is called before the context ends
+	campaign.Window = Window{Start: 9 * time.Hour, End: 17 * time.Hour}
+	campaign.now = func() time.Time { return time.Date(2020, 1, 1, 20, 0, 0, 0, time.UTC) }
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err = campaign.Run(ctx, []Target{{Number: "447700900001"}})
+	if err != context.DeadlineExceeded || report.Outcomes[0].Status != StatusNotCalled {
+		t.Errorf("Target outside the window should not be called: %s %+v", err, report.Outcomes)
+	}
+
+	if _, err := (&Campaign{}).Run(context.Background(), nil); err == nil {
+		t.Errorf("Campaign without a client should fail")
+	}
+}
+
+func TestCampaignIgnoresOtherCalls(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	campaign := newCampaign()
+	fake := &fakeCalls{script: map[string][]string{"447700900001": {"completed"}}}
+	fake.register(campaign)
+
+	// calls on the same application that the campaign didn't make
+	other := voicewebhook.CallEvent{Uuid: "other-call", Status: "completed"}
+	sendOther := func() {
+		campaign.HandleEvent(voicewebhook.MachineEvent{CallEvent: voicewebhook.CallEvent{Uuid: "other-call", Status: "machine"}})
+		campaign.HandleEvent(voicewebhook.CompletedEvent{CallEvent: other})
+		campaign.HandleEvent(voicewebhook.UnsuccessfulEvent{CallEvent: voicewebhook.CallEvent{Uuid: "another-call", Status: "busy"}})
+	}
+	recorded := func() int {
+		campaign.mu.Lock()
+		defer campaign.mu.Unlock()
+		return len(campaign.finished) + len(campaign.machine)
+	}
+
+	sendOther()
+	campaign.OnOutcome = func(Outcome) {
+		sendOther()
+		if n := recorded(); n != 0 {
+			t.Errorf("Events for other calls should be ignored during a run, %d recorded", n)
+		}
+	}
+	report, err := campaign.Run(context.Background(), []Target{{Number: "447700900001", Data: map[string]string{"name": "Jo", "time": "3pm"}}})
+	if err != nil || report.Outcomes[0].Status != "completed" {
+		t.Fatalf("Unexpected report: %v %+v", err, report.Outcomes)
+	}
+
+	sendOther()
+	campaign.HandleEvent(voicewebhook.CompletedEvent{CallEvent: voicewebhook.CallEvent{Uuid: "call-1", Status: "completed"}})
+	if n := recorded(); n != 0 {
+		t.Errorf("Events after the run should be ignored, %d recorded", n)
+	}
+}
+
+func TestFillTemplateQuotes(t *testing.T) {
+	template := ncco.Ncco{}
+	template.AddAction(ncco.TalkAction{Text: "{{greeting}}, {{name}}"})
+
+	filled, err := fillTemplate(template, map[string]string{"greeting": `say "hi"`, "name": `"Jo"`})
+	if err != nil {
+		t.Fatalf("Template with quoted data failed: %s", err)
+	}
+	talk, ok := filled.GetActions()[0].(ncco.TalkAction)
+	if !ok || talk.Text != `say "hi", "Jo"` {
+		t.Errorf("Quotes in the data should be kept: %+v", filled.GetActions())
+	}
+}
+
+func TestCampaignWindow(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*60*60)
+	campaign := &Campaign{Window: Window{
+		Start: 9 * time.Hour,
+		End:   17*time.Hour + 30*time.Minute,
+		Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}}
+
+	// Wednesday 1 January 2020, 12:00 UTC is 07:00 in New York
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		target   Target
+		now      time.Time
+		expected time.Time
+	}{
+		{"open in UTC", Target{}, now, now},
+		{"before opening", Target{Location: newYork}, now, time.Date(2020, 1, 1, 14, 0, 0, 0, time.UTC)},
+		{"after closing", Target{}, now.Add(6 * time.Hour), time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"weekend", Target{}, time.Date(2020, 1, 4, 10, 0, 0, 0, time.UTC), time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if open := campaign.nextOpen(c.now, c.target); !open.Equal(c.expected) {
+			t.Errorf("Unexpected window opening %s: %s", c.name, open)
+		}
+	}
+
+	campaign.Window = Window{Start: 17 * time.Hour, End: 9 * time.Hour}
+	if campaign.validate() == nil {
+		t.Errorf("Window ending before it starts should be invalid")
+	}
+}
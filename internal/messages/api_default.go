@@ -0,0 +1,115 @@
+/*
+ * Messages API
+ *
+ * The Messages API consolidates and normalises exchanges across all messaging channels. It allows you to use a single API to interact with our various channels, such as SMS, MMS, WhatsApp, Viber and Facebook Messenger. More information is available at <https://developer.nexmo.com/messages/overview>.
+ *
+ * API version: 1.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package messages
+
+import (
+	_context "context"
+	_ioutil "io/ioutil"
+	_nethttp "net/http"
+	_neturl "net/url"
+
+	"github.com/antihax/optional"
+)
+
+// Linger please
+var (
+	_ _context.Context
+)
+
+// DefaultApiService DefaultApi service
+type DefaultApiService service
+
+// SendMessageOpts Optional parameters for the method 'SendMessage'
+type SendMessageOpts struct {
+	Message optional.Interface
+}
+
+/*
+SendMessage Send a Message
+Send a Message
+ * @param ctx _context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @param optional nil or *SendMessageOpts - Optional Parameters:
+ * @param "Message" (optional.Interface of UNKNOWN_BASE_TYPE) -  Message Details
+@return SendMessageResponse
+*/
+func (a *DefaultApiService) SendMessage(ctx _context.Context, localVarOptionals *SendMessageOpts) (SendMessageResponse, *_nethttp.Response, error) {
+	var (
+		localVarHTTPMethod   = _nethttp.MethodPost
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  SendMessageResponse
+	)
+
+	// create path and map variables
+	localVarPath := a.client.cfg.BasePath
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := _neturl.Values{}
+	localVarFormParams := _neturl.Values{}
+
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{"application/json"}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	// body params
+	if localVarOptionals != nil && localVarOptionals.Message.IsSet() {
+		localVarPostBody = localVarOptionals.Message.Value()
+	}
+
+	r, err := a.client.prepareRequest(ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(r)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := _ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
@@ -0,0 +1,24 @@
+/*
+ * Messages API
+ *
+ * The Messages API consolidates and normalises exchanges across all messaging channels. It allows you to use a single API to interact with our various channels, such as SMS, MMS, WhatsApp, Viber and Facebook Messenger. More information is available at <https://developer.nexmo.com/messages/overview>.
+ *
+ * API version: 1.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package messages
+// ErrorResponse struct for ErrorResponse
+type ErrorResponse struct {
+	// The type of error, a link to the error documentation
+	Type string `json:"type,omitempty"`
+	// The title of the error
+	Title string `json:"title,omitempty"`
+	// A detailed description of the error
+	Detail string `json:"detail,omitempty"`
+	// The unique identifier for this request, for use in support requests
+	Instance string `json:"instance,omitempty"`
+	// Which request parameters were invalid, if any
+	InvalidParameters []ErrorResponseInvalidParameters `json:"invalid_parameters,omitempty"`
+}
@@ -0,0 +1,18 @@
+/*
+ * Messages API
+ *
+ * The Messages API consolidates and normalises exchanges across all messaging channels. It allows you to use a single API to interact with our various channels, such as SMS, MMS, WhatsApp, Viber and Facebook Messenger. More information is available at <https://developer.nexmo.com/messages/overview>.
+ *
+ * API version: 1.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package messages
+// ErrorResponseInvalidParameters struct for ErrorResponseInvalidParameters
+type ErrorResponseInvalidParameters struct {
+	// The name of the invalid parameter
+	Name string `json:"name,omitempty"`
+	// Why the parameter was rejected
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,18 @@
+/*
+ * Messages API
+ *
+ * The Messages API consolidates and normalises exchanges across all messaging channels. It allows you to use a single API to interact with our various channels, such as SMS, MMS, WhatsApp, Viber and Facebook Messenger. More information is available at <https://developer.nexmo.com/messages/overview>.
+ *
+ * API version: 1.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package messages
+// SendMessageResponse struct for SendMessageResponse
+type SendMessageResponse struct {
+	// The UUID of the message
+	MessageUuid string `json:"message_uuid,omitempty"`
+	// The ID of the failover workflow, only present when the request included a failover
+	WorkflowId string `json:"workflow_id,omitempty"`
+}
@@ -239,6 +239,7 @@ type VerifyRequestOpts struct {
 	PinExpiry     optional.Int32
 	NextEventWait optional.Int32
 	WorkflowId    optional.Int32
+	PinCode       optional.String
 }
 
 /*
@@ -323,6 +324,9 @@ func (a *DefaultApiService) VerifyRequest(ctx _context.Context, format string, a
 	if localVarOptionals != nil && localVarOptionals.WorkflowId.IsSet() {
 		localVarFormParams.Add("workflow_id", parameterToString(localVarOptionals.WorkflowId.Value(), ""))
 	}
+	if localVarOptionals != nil && localVarOptionals.PinCode.IsSet() {
+		localVarFormParams.Add("pin_code", parameterToString(localVarOptionals.PinCode.Value(), ""))
+	}
 	r, err := a.client.prepareRequest(ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
 	if err != nil {
 		return localVarReturnValue, nil, err
@@ -335,6 +339,10 @@ func (a *DefaultApiService) VerifyRequest(ctx _context.Context, format string, a
 
 	localVarBody, err := _ioutil.ReadAll(localVarHTTPResponse.Body)
 	localVarHTTPResponse.Body.Close()
+
+	// keep the body so that the caller can read the error status
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+
 	if err != nil {
 		return localVarReturnValue, localVarHTTPResponse, err
 	}
@@ -362,11 +370,13 @@ func (a *DefaultApiService) VerifyRequest(ctx _context.Context, format string, a
 // VerifyRequestWithPSD2Opts Optional parameters for the method 'VerifyRequestWithPSD2'
 type VerifyRequestWithPSD2Opts struct {
 	Country       optional.String
+	SenderId      optional.String
 	CodeLength    optional.Int32
 	Lg            optional.String
 	PinExpiry     optional.Int32
 	NextEventWait optional.Int32
 	WorkflowId    optional.Int32
+	PinCode       optional.String
 }
 
 /*
@@ -434,6 +444,9 @@ func (a *DefaultApiService) VerifyRequestWithPSD2(ctx _context.Context, format s
 	}
 	localVarFormParams.Add("payee", parameterToString(payee, ""))
 	localVarFormParams.Add("amount", parameterToString(amount, ""))
+	if localVarOptionals != nil && localVarOptionals.SenderId.IsSet() {
+		localVarFormParams.Add("sender_id", parameterToString(localVarOptionals.SenderId.Value(), ""))
+	}
 	if localVarOptionals != nil && localVarOptionals.CodeLength.IsSet() {
 		localVarFormParams.Add("code_length", parameterToString(localVarOptionals.CodeLength.Value(), ""))
 	}
@@ -449,6 +462,9 @@ func (a *DefaultApiService) VerifyRequestWithPSD2(ctx _context.Context, format s
 	if localVarOptionals != nil && localVarOptionals.WorkflowId.IsSet() {
 		localVarFormParams.Add("workflow_id", parameterToString(localVarOptionals.WorkflowId.Value(), ""))
 	}
+	if localVarOptionals != nil && localVarOptionals.PinCode.IsSet() {
+		localVarFormParams.Add("pin_code", parameterToString(localVarOptionals.PinCode.Value(), ""))
+	}
 	r, err := a.client.prepareRequest(ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
 	if err != nil {
 		return localVarReturnValue, nil, err
@@ -462,7 +478,7 @@ func (a *DefaultApiService) VerifyRequestWithPSD2(ctx _context.Context, format s
 	localVarBody, err := _ioutil.ReadAll(localVarHTTPResponse.Body)
 	localVarHTTPResponse.Body.Close()
 
-	// hack to reinstate the body in case we need it
+	// keep the body so that the caller can read the error status
 	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
 
 	if err != nil {
@@ -0,0 +1,16 @@
+/*
+ * Verify API
+ *
+ * The Verify API helps you to implement 2FA (two-factor authentication) in your applications. Version 2 sends the code over a workflow of channels, such as SMS, WhatsApp, voice and email, moving on to the next channel when the user has not entered the code in time. More information is available at <https://developer.nexmo.com/verify/overview>.
+ *
+ * API version: 2.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package verify2
+// CheckRequest struct for CheckRequest
+type CheckRequest struct {
+	// The code the user entered
+	Code string `json:"code"`
+}
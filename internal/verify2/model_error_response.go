@@ -0,0 +1,26 @@
+/*
+ * Verify API
+ *
+ * The Verify API helps you to implement 2FA (two-factor authentication) in your applications. Version 2 sends the code over a workflow of channels, such as SMS, WhatsApp, voice and email, moving on to the next channel when the user has not entered the code in time. More information is available at <https://developer.nexmo.com/verify/overview>.
+ *
+ * API version: 2.0.0
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package verify2
+// ErrorResponse struct for ErrorResponse
+type ErrorResponse struct {
+	// The type of error, a link to the error documentation
+	Type string `json:"type,omitempty"`
+	// The title of the error
+	Title string `json:"title,omitempty"`
+	// A detailed description of the error
+	Detail string `json:"detail,omitempty"`
+	// The unique identifier for this request, for use in support requests
+	Instance string `json:"instance,omitempty"`
+	// The ID of the verify request, if the error is about one
+	RequestId string `json:"request_id,omitempty"`
+	// Which request parameters were invalid, if any
+	InvalidParameters []ErrorResponseInvalidParameters `json:"invalid_parameters,omitempty"`
+}
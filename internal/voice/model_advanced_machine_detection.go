@@ -0,0 +1,20 @@
+/*
+ * Voice API
+ *
+ * The Voice API lets you create outbound calls, control in-progress calls and get information about historical calls. More information about the Voice API can be found at <https://developer.nexmo.com/voice/voice-api/overview>.
+ *
+ * API version: 1.3.2
+ * Contact: devrel@nexmo.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package voice
+// AdvancedMachineDetection Configure the behavior of Vonage's advanced machine detection. Overrides machine_detection if both are set.
+type AdvancedMachineDetection struct {
+	// How Vonage should behave when it detects that a call is answered by voicemail, `continue` or `hangup`
+	Behavior string `json:"behavior,omitempty"`
+	// Detect if machine answered and sends a human or machine status in the webhook payload. When set to `detect_beep`, the system also attempts to detect voice mail beep and sends an additional parameter `sub_state` in the webhook with the value `beep_start`
+	Mode string `json:"mode,omitempty"`
+	// Maximum time in seconds Vonage should wait for a machine beep to be detected. A machine event with `sub_state` set to `beep_timeout` will be sent if the timeout is exceeded.
+	BeepTimeout int32 `json:"beep_timeout,omitempty"`
+}
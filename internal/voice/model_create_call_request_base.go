@@ -12,14 +12,18 @@ package voice
 
 // CreateCallRequestBase struct for CreateCallRequestBase
 type CreateCallRequestBase struct {
-	To   []EndpointPhoneTo `json:"to"`
-	From EndpointPhoneFrom `json:"from"`
+	// The endpoints to connect to, any of EndpointPhoneTo, EndpointSip, EndpointWebsocket, EndpointApp or EndpointVbcExtension
+	To   []interface{} `json:"to"`
+	From *EndpointPhoneFrom `json:"from,omitempty"`
+	// Set to `true` to use random phone number as `from`. The number will be selected from the list of the numbers assigned to the current application. `random_from_number: true` cannot be used together with `from`.
+	RandomFromNumber bool `json:"random_from_number,omitempty"`
 	// **Required** unless `event_url` is configured at the application level, see [Create an Application](/api/application.v2#createApplication)  The webhook endpoint where call progress events are sent to. For more information about the values sent, see [Event webhook](/voice/voice-api/webhook-reference#event-webhook).
 	EventUrl []string `json:"event_url,omitempty"`
 	// The HTTP method used to send event information to event_url.
 	EventMethod string `json:"event_method,omitempty"`
 	// Configure the behavior when Nexmo detects that the call is answered by voicemail. If Continue Nexmo sends an HTTP request to event_url with the Call event machine. hangup  end the call
 	MachineDetection string `json:"machine_detection,omitempty"`
+	AdvancedMachineDetection *AdvancedMachineDetection `json:"advanced_machine_detection,omitempty"`
 	// Set the number of seconds that elapse before Nexmo hangs up after the call state changes to answered.
 	LengthTimer int32 `json:"length_timer,omitempty"`
 	// Set the number of seconds that elapse before Nexmo hangs up after the call state changes to ‘ringing’.
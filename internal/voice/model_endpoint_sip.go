@@ -15,4 +15,8 @@ type EndpointSip struct {
 	Type string `json:"type"`
 	// The SIP URI to connect to
 	Uri string `json:"uri,omitempty"`
+	// Custom headers to send with the SIP INVITE, these are prefixed with `X-`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Standard SIP headers to send, such as `User-to-User`
+	StandardHeaders map[string]string `json:"standard_headers,omitempty"`
 }
@@ -15,5 +15,6 @@ type EndpointWebsocket struct {
 	Type string `json:"type"`
 	Uri string `json:"uri,omitempty"`
 	ContentType string `json:"content-type"`
-	Headers EndpointWebsocketHeaders `json:"headers,omitempty"`
+	// Details of the Websocket you want to connect to, any headers you need are passed in the initial message
+	Headers map[string]string `json:"headers,omitempty"`
 }
@@ -12,4 +12,8 @@ package voice
 // GetCallsResponseLinks struct for GetCallsResponseLinks
 type GetCallsResponseLinks struct {
 	Self GetCallsResponseLinksSelf `json:"self,omitempty"`
+	First GetCallsResponseLinksSelf `json:"first,omitempty"`
+	Last GetCallsResponseLinksSelf `json:"last,omitempty"`
+	Next GetCallsResponseLinksSelf `json:"next,omitempty"`
+	Prev GetCallsResponseLinksSelf `json:"prev,omitempty"`
 }
@@ -12,8 +12,9 @@ package voice
 // StartStreamRequest struct for StartStreamRequest
 type StartStreamRequest struct {
 	StreamUrl []string `json:"stream_url"`
-	// the number of times to play the file, 0 for infinite
-	Loop int32 `json:"loop,omitempty"`
+	// the number of times to play the file, 0 for infinite. Not omitempty, as
+	// leaving out 0 would play it once instead
+	Loop int32 `json:"loop"`
 	// Set the audio level of the stream in the range `-1 >= level <= 1` with a precision of 0.1. The default value is 0.
 	Level string `json:"level,omitempty"`
 }
@@ -14,8 +14,15 @@ type StartTalkRequest struct {
 	// The text to read
 	Text string `json:"text"`
 	VoiceName VoiceName `json:"voice_name,omitempty"`
-	// The number of times to repeat the text the file, 0 for infinite
-	Loop int32 `json:"loop,omitempty"`
+	// The number of times to repeat the text the file, 0 for infinite. Not omitempty, as
+	// leaving out 0 would play it once instead
+	Loop int32 `json:"loop"`
 	// The volume level that the speech is played. This can be any value between `-1` to `1` in `0.1` increments, with `0` being the default.
 	Level string `json:"level,omitempty"`
+	// The language (BCP-47 format) for the message you are sending
+	Language string `json:"language,omitempty"`
+	// The vocal style (vocal range, tessitura, and timbre)
+	Style int32 `json:"style,omitempty"`
+	// Set to true to use the premium version of the specified style
+	Premium bool `json:"premium,omitempty"`
 }
@@ -0,0 +1,422 @@
+// Package ivr builds multi-step voice menus from a set of nodes. Each node
+// plays a prompt, collects DTMF or speech input and branches to the next
+// node, and the IVR serves the NCCO for each step as an http.Handler
+package ivr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+// Node is one step of the menu. A node with no Branches and no Route is
+// the end of the menu: its Prompt is played and the IVR stops collecting
+// input, so the prompt can finish with actions like connect or record
+type Node struct {
+	ID     string
+	Prompt []ncco.Action
+	// Input is used as the input action for this node, the IVR sets the
+	// eventUrl. Set Input.Speech to accept speech; when neither DTMF nor
+	// speech is set it collects as many digits as the longest branch
+	Input ncco.InputAction
+	// Branches maps the expected DTMF digits or spoken words to the ID of
+	// the next node. Speech is matched ignoring case
+	Branches map[string]string
+	// Route is called for input that doesn't match a branch, return the
+	// ID of the next node or "" if the input is invalid
+	Route func(session Session, input voicewebhook.InputEvent) string
+	// Retries is how many more times the prompt is played after invalid
+	// input or a timeout, before moving to OnFailure
+	Retries       int
+	InvalidPrompt []ncco.Action
+	TimeoutPrompt []ncco.Action
+	// OnFailure is the node to go to once the retries are used up. When
+	// it is empty the call ends
+	OnFailure string
+}
+
+func (n Node) collectsInput() bool {
+	return len(n.Branches) > 0 || n.Route != nil
+}
+
+// Session is the state of one call as it moves through the menu
+type Session struct {
+	Uuid             string
+	ConversationUuid string
+	Node             string
+	Attempts         int
+	// Inputs holds the input that was accepted at each node
+	Inputs  map[string]string
+	History []string
+}
+
+// copy returns the session with its own Inputs and History, so changing
+// it doesn't change a stored session
+func (s Session) copy() Session {
+	inputs := make(map[string]string, len(s.Inputs))
+	for node, input := range s.Inputs {
+		inputs[node] = input
+	}
+	s.Inputs = inputs
+	s.History = append([]string(nil), s.History...)
+	return s
+}
+
+// SessionStore keeps the sessions for an IVR. Implementations must be
+// safe to use from several goroutines
+type SessionStore interface {
+	Get(uuid string) (Session, bool, error)
+	Put(session Session) error
+	Delete(uuid string) error
+}
+
+// MemorySessionStore is a SessionStore that keeps sessions in a map
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[string]Session{}}
+}
+
+// Get returns the session for the call with this uuid
+func (s *MemorySessionStore) Get(uuid string) (Session, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[uuid]
+	if !ok {
+		return Session{}, false, nil
+	}
+	return session.copy(), true, nil
+}
+
+// Put saves the session
+func (s *MemorySessionStore) Put(session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.Uuid] = session.copy()
+	return nil
+}
+
+// Delete removes the session
+func (s *MemorySessionStore) Delete(uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, uuid)
+	return nil
+}
+
+// badRequest is a webhook that can't be read, as opposed to an error
+// from the IVR or its SessionStore
+type badRequest struct {
+	error
+}
+
+// IVR serves a menu. Use BaseUrl + "/answer" as the answer URL for your
+// application and BaseUrl + "/event" as the event URL so that sessions
+// are removed when calls end; input events go to BaseUrl + "/input"
+type IVR struct {
+	BaseUrl string
+	Start   string
+	Store   SessionStore
+	nodes   map[string]Node
+}
+
+// New creates an IVR that starts at the node with ID start, checking
+// that every branch leads to a node that exists
+func New(baseUrl string, start string, nodes ...Node) (*IVR, error) {
+	ivr := &IVR{
+		BaseUrl: strings.TrimSuffix(baseUrl, "/"),
+		Start:   start,
+		Store:   NewMemorySessionStore(),
+		nodes:   map[string]Node{},
+	}
+
+	for _, node := range nodes {
+		if node.ID == "" {
+			return nil, errors.New("Every node needs an ID")
+		}
+		if _, exists := ivr.nodes[node.ID]; exists {
+			return nil, fmt.Errorf("Duplicate node ID %q", node.ID)
+		}
+		if node.Retries < 0 {
+			return nil, fmt.Errorf("Node %q has negative retries", node.ID)
+		}
+		ivr.nodes[node.ID] = node
+	}
+
+	if _, ok := ivr.nodes[start]; !ok {
+		return nil, fmt.Errorf("Start node %q does not exist", start)
+	}
+	for _, node := range ivr.nodes {
+		for input, next := range node.Branches {
+			if _, ok := ivr.nodes[next]; !ok {
+				return nil, fmt.Errorf("Node %q branch %q goes to unknown node %q", node.ID, input, next)
+			}
+		}
+		if _, ok := ivr.nodes[node.OnFailure]; node.OnFailure != "" && !ok {
+			return nil, fmt.Errorf("Node %q fails to unknown node %q", node.ID, node.OnFailure)
+		}
+	}
+	return ivr, nil
+}
+
+// ServeHTTP handles the answer, input and event webhooks
+func (ivr *IVR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var n ncco.Ncco
+	var err error
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/answer"):
+		n, err = ivr.answer(r)
+	case strings.HasSuffix(r.URL.Path, "/input"):
+		n, err = ivr.input(r)
+	case strings.HasSuffix(r.URL.Path, "/event"):
+		err = ivr.event(r)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if _, ok := err.(badRequest); ok {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	body, _ := json.Marshal(n)
+	if len(n.GetActions()) == 0 {
+		body = []byte("[]")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (ivr *IVR) answer(r *http.Request) (ncco.Ncco, error) {
+	answer, err := voicewebhook.ParseAnswerRequest(r)
+	if err != nil {
+		return ncco.Ncco{}, badRequest{err}
+	}
+	return ivr.begin(answer.Uuid, answer.ConversationUuid)
+}
+
+func (ivr *IVR) begin(uuid string, conversationUuid string) (ncco.Ncco, error) {
+	session := Session{
+		Uuid:             uuid,
+		ConversationUuid: conversationUuid,
+		Inputs:           map[string]string{},
+	}
+	return ivr.moveTo(session, ivr.Start, nil)
+}
+
+func (ivr *IVR) input(r *http.Request) (ncco.Ncco, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return ncco.Ncco{}, badRequest{err}
+	}
+	event, err := voicewebhook.ParseEvent(body)
+	if err != nil {
+		return ncco.Ncco{}, badRequest{err}
+	}
+	input, ok := event.(voicewebhook.InputEvent)
+	if !ok {
+		return ncco.Ncco{}, badRequest{errors.New("Expected an input event")}
+	}
+
+	session, found, err := ivr.Store.Get(input.Uuid)
+	if err != nil {
+		return ncco.Ncco{}, err
+	}
+	node, known := ivr.nodes[session.Node]
+	if !found || !known {
+		// the session has gone, so start the menu again
+		return ivr.begin(input.Uuid, input.ConversationUuid)
+	}
+
+	if accepted, next := ivr.match(node, session, input); next != "" {
+		session.Inputs[node.ID] = accepted
+		return ivr.moveTo(session, next, nil)
+	}
+
+	session.Attempts++
+	if session.Attempts > node.Retries {
+		if node.OnFailure == "" {
+			return ncco.Ncco{}, ivr.Store.Delete(session.Uuid)
+		}
+		return ivr.moveTo(session, node.OnFailure, nil)
+	}
+
+	before := node.InvalidPrompt
+	if noInput(input) && len(node.TimeoutPrompt) > 0 {
+		before = node.TimeoutPrompt
+	}
+	if err := ivr.Store.Put(session); err != nil {
+		return ncco.Ncco{}, err
+	}
+	return ivr.nodeNcco(node, before), nil
+}
+
+func (ivr *IVR) event(r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return badRequest{err}
+	}
+	event, err := voicewebhook.ParseEvent(body)
+	if err != nil {
+		return badRequest{err}
+	}
+
+	switch e := event.(type) {
+	case voicewebhook.CompletedEvent:
+		return ivr.Store.Delete(e.Uuid)
+	case voicewebhook.UnsuccessfulEvent:
+		return ivr.Store.Delete(e.Uuid)
+	}
+	return nil
+}
+
+// match returns the accepted input and the next node, or an empty next
+// node when the input is invalid
+func (ivr *IVR) match(node Node, session Session, input voicewebhook.InputEvent) (string, string) {
+	candidates := []string{}
+	if input.Dtmf != nil && input.Dtmf.Digits != "" {
+		candidates = append(candidates, input.Dtmf.Digits)
+	}
+	if input.Speech != nil {
+		for _, result := range input.Speech.Results {
+			candidates = append(candidates, strings.TrimSpace(result.Text))
+		}
+	}
+
+	for _, candidate := range candidates {
+		for expected, next := range node.Branches {
+			if strings.EqualFold(candidate, expected) {
+				return expected, next
+			}
+		}
+	}
+
+	if node.Route != nil {
+		if next := node.Route(session, input); next != "" {
+			if _, ok := ivr.nodes[next]; ok {
+				accepted := ""
+				if len(candidates) > 0 {
+					accepted = candidates[0]
+				}
+				return accepted, next
+			}
+		}
+	}
+	return "", ""
+}
+
+func (ivr *IVR) moveTo(session Session, id string, before []ncco.Action) (ncco.Ncco, error) {
+	node := ivr.nodes[id]
+	session.Node = id
+	session.Attempts = 0
+	session.History = append(session.History, id)
+
+	var err error
+	if node.collectsInput() {
+		err = ivr.Store.Put(session)
+	} else {
+		err = ivr.Store.Delete(session.Uuid)
+	}
+	if err != nil {
+		return ncco.Ncco{}, err
+	}
+	return ivr.nodeNcco(node, before), nil
+}
+
+// nodeNcco plays any actions in before, then the node's prompt, then
+// collects input if the node has branches
+func (ivr *IVR) nodeNcco(node Node, before []ncco.Action) ncco.Ncco {
+	n := ncco.Ncco{}
+	for _, action := range before {
+		n.AddAction(action)
+	}
+	for _, action := range node.Prompt {
+		n.AddAction(action)
+	}
+
+	if node.collectsInput() {
+		input := node.Input
+		input.EventUrl = []string{ivr.BaseUrl + "/input"}
+		input.EventMethod = "POST"
+		if input.Dtmf == nil && input.Speech == nil {
+			input.Dtmf = &ncco.DtmfInput{MaxDigits: maxDigits(node.Branches)}
+		}
+		n.AddAction(input)
+	}
+	return n
+}
+
+// maxDigits is the length of the longest numeric branch, at least one
+func maxDigits(branches map[string]string) int {
+	max := 1
+	for input := range branches {
+		if _, err := strconv.Atoi(input); err == nil && len(input) > max {
+			max = len(input)
+		}
+	}
+	return max
+}
+
+func noInput(input voicewebhook.InputEvent) bool {
+	_, spoke := input.BestTranscript()
+	return input.Digits() == "" && !spoke
+}
+
+// Diagram draws the menu with one NCCO per node, linked by the input that
+// leads to the next node. Routes can't be drawn as their targets are only
+// known at runtime
+func (ivr *IVR) Diagram() *ncco.Diagram {
+	ids := []string{}
+	for id := range ivr.nodes {
+		if id != ivr.Start {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	ids = append([]string{ivr.Start}, ids...)
+
+	d := &ncco.Diagram{}
+	for _, id := range ids {
+		d.AddNcco(id, ivr.nodeNcco(ivr.nodes[id], nil))
+	}
+	for _, id := range ids {
+		node := ivr.nodes[id]
+		inputs := []string{}
+		for input := range node.Branches {
+			inputs = append(inputs, input)
+		}
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			d.Link(id, node.Branches[input], input)
+		}
+		if node.collectsInput() && node.Retries > 0 {
+			d.Link(id, id, fmt.Sprintf("retry x%d", node.Retries))
+		}
+		if node.OnFailure != "" {
+			d.Link(id, node.OnFailure, "failure")
+		}
+	}
+	return d
+}
@@ -0,0 +1,195 @@
+package ivr
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+func testMenu(t *testing.T) *IVR {
+	menu, err := New("https://example.com/ivr/", "main",
+		Node{
+			ID:            "main",
+			Prompt:        []ncco.Action{ncco.TalkAction{Text: "Press 1 for sales or 2 for support"}},
+			Branches:      map[string]string{"1": "sales", "2": "support", "sales": "sales"},
+			Retries:       1,
+			InvalidPrompt: []ncco.Action{ncco.TalkAction{Text: "Sorry"}},
+			TimeoutPrompt: []ncco.Action{ncco.TalkAction{Text: "Are you there?"}},
+			OnFailure:     "goodbye",
+		},
+		Node{
+			ID:     "sales",
+			Prompt: []ncco.Action{ncco.TalkAction{Text: "Connecting you to sales"}},
+		},
+		Node{
+			ID:      "support",
+			Prompt:  []ncco.Action{ncco.TalkAction{Text: "Enter your account number"}},
+			Input:   ncco.InputAction{Dtmf: &ncco.DtmfInput{MaxDigits: 6, SubmitOnHash: true}},
+			Retries: 2,
+			Route: func(s Session, input voicewebhook.InputEvent) string {
+				if len(input.Dtmf.Digits) == 6 {
+					return "goodbye"
+				}
+				return ""
+			},
+		},
+		Node{
+			ID:     "goodbye",
+			Prompt: []ncco.Action{ncco.TalkAction{Text: "Goodbye"}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Menu should be valid: %s", err)
+	}
+	return menu
+}
+
+func serve(menu *IVR, method string, path string, body string) string {
+	rec := httptest.NewRecorder()
+	menu.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec.Body.String()
+}
+
+func TestIVRAnswerAndBranch(t *testing.T) {
+	menu := testMenu(t)
+
+	body := serve(menu, "GET", "/ivr/answer?uuid=leg-1&conversation_uuid=CON-1", "")
+	expected := `[{"action":"talk","text":"Press 1 for sales or 2 for support","bargeIn":false,"loop":1},{"action":"input","dtmf":{"maxDigits":1},"eventUrl":["https://example.com/ivr/input"],"eventMethod":"POST"}]`
+	if body != expected {
+		t.Errorf("Unexpected JSON format for: IVR answer: %s", body)
+	}
+
+	body = serve(menu, "POST", "/ivr/input", `{"uuid":"leg-1","speech":{"results":[{"confidence":"0.9","text":"Sales"}]}}`)
+	if body != `[{"action":"talk","text":"Connecting you to sales","bargeIn":false,"loop":1}]` {
+		t.Errorf("Unexpected JSON format for: IVR sales node: %s", body)
+	}
+
+	if _, found, _ := menu.Store.Get("leg-1"); found {
+		t.Errorf("Session should end at a node without branches")
+	}
+}
+
+func TestIVRRetriesAndFailure(t *testing.T) {
+	menu := testMenu(t)
+	serve(menu, "GET", "/ivr/answer?uuid=leg-1", "")
+
+	body := serve(menu, "POST", "/ivr/input", `{"uuid":"leg-1","dtmf":{"digits":"","timed_out":true}}`)
+	if !strings.HasPrefix(body, `[{"action":"talk","text":"Are you there?"`) || !strings.Contains(body, `"action":"input"`) {
+		t.Errorf("Unexpected JSON format for: IVR timeout retry: %s", body)
+	}
+
+	session, _, _ := menu.Store.Get("leg-1")
+	if session.Attempts != 1 || session.Node != "main" {
+		t.Errorf("Unexpected session after a retry: %+v", session)
+	}
+
+	body = serve(menu, "POST", "/ivr/input", `{"uuid":"leg-1","dtmf":{"digits":"9","timed_out":false}}`)
+	if body != `[{"action":"talk","text":"Goodbye","bargeIn":false,"loop":1}]` {
+		t.Errorf("Unexpected JSON format for: IVR failure node: %s", body)
+	}
+}
+
+func TestIVRRouteAndSessions(t *testing.T) {
+	menu := testMenu(t)
+	serve(menu, "GET", "/ivr/answer?uuid=leg-1", "")
+	serve(menu, "POST", "/ivr/input", `{"uuid":"leg-1","dtmf":{"digits":"2"}}`)
+
+	session, _, _ := menu.Store.Get("leg-1")
+	if session.Node != "support" || session.Inputs["main"] != "2" {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+
+	body := serve(menu, "POST", "/ivr/input", `{"uuid":"leg-1","dtmf":{"digits":"123"}}`)
+	if !strings.Contains(body, `"dtmf":{"maxDigits":6,"submitOnHash":true}`) {
+		t.Errorf("Unexpected JSON format for: IVR route retry: %s", body)
+	}
+
+	serve(menu, "POST", "/ivr/input", `{"uuid":"leg-2","dtmf":{"digits":"1"}}`)
+	session, _, _ = menu.Store.Get("leg-2")
+	if session.Node != "main" {
+		t.Errorf("Input for an unknown call should start the menu: %+v", session)
+	}
+
+	rec := httptest.NewRecorder()
+	menu.ServeHTTP(rec, httptest.NewRequest("POST", "/ivr/event", strings.NewReader(`{"status":"completed","uuid":"leg-1","duration":"5"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Unexpected status for event: %d", rec.Code)
+	}
+	if _, found, _ := menu.Store.Get("leg-1"); found {
+		t.Errorf("Session should be removed when the call completes")
+	}
+}
+
+// failingStore is a SessionStore that is down
+type failingStore struct{}
+
+func (failingStore) Get(uuid string) (Session, bool, error) {
+	return Session{}, false, errors.New("Store unavailable")
+}
+func (failingStore) Put(session Session) error { return errors.New("Store unavailable") }
+func (failingStore) Delete(uuid string) error  { return errors.New("Store unavailable") }
+
+func TestIVRErrorStatuses(t *testing.T) {
+	menu := testMenu(t)
+	rec := httptest.NewRecorder()
+	menu.ServeHTTP(rec, httptest.NewRequest("POST", "/ivr/input", strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Unreadable webhook should be a bad request: %d", rec.Code)
+	}
+
+	menu.Store = failingStore{}
+	rec = httptest.NewRecorder()
+	menu.ServeHTTP(rec, httptest.NewRequest("GET", "/ivr/answer?uuid=leg-1&conversation_uuid=CON-1", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Store error should be a server error: %d", rec.Code)
+	}
+}
+
+func TestIVRMemoryStoreCopies(t *testing.T) {
+	store := NewMemorySessionStore()
+	store.Put(Session{Uuid: "leg-1", Inputs: map[string]string{"main": "1"}, History: []string{"main"}})
+
+	session, _, _ := store.Get("leg-1")
+	session.Inputs["main"] = "2"
+	session.History[0] = "support"
+
+	stored, _, _ := store.Get("leg-1")
+	if stored.Inputs["main"] != "1" || stored.History[0] != "main" {
+		t.Errorf("Changing a session should not change the stored one: %+v", stored)
+	}
+}
+
+func TestIVRInvalidMenu(t *testing.T) {
+	if _, err := New("https://example.com", "missing", Node{ID: "main"}); err == nil {
+		t.Errorf("Menu with an unknown start node should be invalid")
+	}
+	if _, err := New("https://example.com", "main", Node{ID: "main", Branches: map[string]string{"1": "nowhere"}}); err == nil {
+		t.Errorf("Menu with an unknown branch should be invalid")
+	}
+	if _, err := New("https://example.com", "main", Node{ID: "main"}, Node{ID: "main"}); err == nil {
+		t.Errorf("Menu with duplicate nodes should be invalid")
+	}
+}
+
+func TestIVRDiagram(t *testing.T) {
+	menu := testMenu(t)
+	mermaid := menu.Diagram().Mermaid()
+
+	for _, expected := range []string{
+		`  subgraph s0["main"]`,
+		`    n0_1{"input: dtmf 1 digits"}`,
+		`  n0_1 -->|"1"| n2_0`,
+		`  n0_1 -->|"retry x1"| n0_0`,
+		`  n0_1 -->|"failure"| n1_0`,
+		`  subgraph s3["support"]`,
+	} {
+		if !strings.Contains(mermaid, expected) {
+			t.Errorf("Diagram should contain %s:\n%s", expected, mermaid)
+		}
+	}
+}
@@ -0,0 +1,805 @@
+package vonage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/vonage/vonage-go-sdk/internal/messages"
+)
+
+// MessagesClient for working with the Messages API
+type MessagesClient struct {
+	Config    *messages.Configuration
+	JWT       string
+	apiKey    string
+	apiSecret string
+}
+
+// NewMessagesClient Creates a new Messages Client, supplying an Auth to work with.
+// The Messages API accepts either a JWT or an API key and secret
+func NewMessagesClient(Auth Auth) *MessagesClient {
+	client := new(MessagesClient)
+	creds := Auth.GetCreds()
+
+	client.Config = messages.NewConfiguration()
+	client.Config.UserAgent = GetUserAgent()
+
+	// JWTAuth has one credential, KeySecretAuth has two
+	if len(creds) == 1 {
+		client.JWT = creds[0]
+		client.Config.AddDefaultHeader("Authorization", "Bearer "+client.JWT)
+	} else {
+		client.apiKey = creds[0]
+		client.apiSecret = creds[1]
+	}
+	return client
+}
+
+// MessagesMessage is implemented by all the message types, each one
+// knows its own channel and message_type and sets them in prepare()
+type MessagesMessage interface {
+	prepare() MessagesMessage
+}
+
+// messagesValidator is implemented by the message types that can check
+// themselves against the channel limits before they are sent
+type messagesValidator interface {
+	Validate() error
+}
+
+// MessagesResponse is returned when a message is accepted for delivery
+type MessagesResponse struct {
+	MessageUuid string
+	WorkflowId  string
+}
+
+// MessagesErrorResponse holds the problem details returned with an error
+type MessagesErrorResponse struct {
+	Type              string
+	Title             string
+	Detail            string
+	Instance          string
+	InvalidParameters []messages.ErrorResponseInvalidParameters
+}
+
+// Send a message on any of the supported channels. Use one of the message
+// types such as MessagesSMSText or MessagesWhatsAppImage to build the message
+func (client *MessagesClient) Send(message MessagesMessage) (MessagesResponse, MessagesErrorResponse, error) {
+	messagesClient := messages.NewAPIClient(client.Config)
+
+	if err := validateMessage(message); err != nil {
+		return MessagesResponse{}, MessagesErrorResponse{}, err
+	}
+
+	sendOpts := messages.SendMessageOpts{Message: optional.NewInterface(message.prepare())}
+
+	ctx := context.Background()
+	if client.JWT == "" {
+		ctx = context.WithValue(ctx, messages.ContextBasicAuth, messages.BasicAuth{
+			UserName: client.apiKey,
+			Password: client.apiSecret,
+		})
+	}
+
+	result, _, err := messagesClient.DefaultApi.SendMessage(ctx, &sendOpts)
+	if err != nil {
+		e, ok := err.(messages.GenericOpenAPIError)
+		if ok {
+			data := e.Body()
+
+			var errResp messages.ErrorResponse
+			jsonErr := json.Unmarshal(data, &errResp)
+			if jsonErr == nil {
+				return MessagesResponse{}, MessagesErrorResponse(errResp), err
+			}
+		}
+
+		// this catches other error types
+		return MessagesResponse{}, MessagesErrorResponse{}, err
+	}
+
+	return MessagesResponse(result), MessagesErrorResponse{}, nil
+}
+
+// validateMessage checks the message, and each of its failover messages,
+// when they can validate themselves
+func validateMessage(message MessagesMessage) error {
+	if validator, ok := message.(messagesValidator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// every message type keeps its failover messages in a Failover field
+	value := reflect.Indirect(reflect.ValueOf(message))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	field := value.FieldByName("Failover")
+	if !field.IsValid() {
+		return nil
+	}
+	failover, _ := field.Interface().([]MessagesMessage)
+	for i, f := range failover {
+		if err := validateMessage(f); err != nil {
+			return fmt.Errorf("Failover message %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// prepareFailover prepares each of the failover messages, without changing
+// the slice that was supplied
+func prepareFailover(failover []MessagesMessage) []MessagesMessage {
+	if len(failover) == 0 {
+		return nil
+	}
+	prepared := make([]MessagesMessage, len(failover))
+	for i, message := range failover {
+		prepared[i] = message.prepare()
+	}
+	return prepared
+}
+
+//--- Message content ---
+
+// MessagesImage is an image to send, by URL
+type MessagesImage struct {
+	Url     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// MessagesAudio is an audio file to send, by URL
+type MessagesAudio struct {
+	Url     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// MessagesVideo is a video file to send, by URL
+type MessagesVideo struct {
+	Url     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// MessagesFile is any other file to send, by URL
+type MessagesFile struct {
+	Url     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// MessagesVcard is a contact card to send, by URL
+type MessagesVcard struct {
+	Url     string `json:"url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// MessagesSticker is a WhatsApp sticker, supply either a URL or a sticker ID
+type MessagesSticker struct {
+	Url string `json:"url,omitempty"`
+	Id  string `json:"id,omitempty"`
+}
+
+// MessagesTemplate is a pre-approved WhatsApp template
+type MessagesTemplate struct {
+	Name       string   `json:"name"`
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+//--- Channel settings ---
+
+// MessagesSMSSettings holds the SMS-specific options
+type MessagesSMSSettings struct {
+	EncodingType string `json:"encoding_type,omitempty"`
+	ContentId    string `json:"content_id,omitempty"`
+	EntityId     string `json:"entity_id,omitempty"`
+}
+
+// MessagesWhatsAppSettings holds the WhatsApp-specific options, used with templates
+type MessagesWhatsAppSettings struct {
+	Policy string `json:"policy,omitempty"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// MessagesViberSettings holds the Viber-specific options
+type MessagesViberSettings struct {
+	Category string `json:"category,omitempty"`
+	TTL      int    `json:"ttl,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// MessagesMessengerSettings holds the Facebook Messenger-specific options
+type MessagesMessengerSettings struct {
+	Category string `json:"category,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+//--- SMS ---
+
+// MessagesSMSText is a text message sent by SMS
+type MessagesSMSText struct {
+	MessageType    string               `json:"message_type"`
+	Channel        string               `json:"channel"`
+	To             string               `json:"to"`
+	From           string               `json:"from"`
+	Text           string               `json:"text"`
+	SMS            *MessagesSMSSettings `json:"sms,omitempty"`
+	TTL            int                  `json:"ttl,omitempty"`
+	ClientRef      string               `json:"client_ref,omitempty"`
+	WebhookUrl     string               `json:"webhook_url,omitempty"`
+	WebhookVersion string               `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage    `json:"failover,omitempty"`
+}
+
+func (m MessagesSMSText) prepare() MessagesMessage {
+	m.MessageType = "text"
+	m.Channel = "sms"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+//--- MMS ---
+
+// MessagesMMSImage is an image sent by MMS
+type MessagesMMSImage struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Image          MessagesImage     `json:"image"`
+	TTL            int               `json:"ttl,omitempty"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesMMSImage) prepare() MessagesMessage {
+	m.MessageType = "image"
+	m.Channel = "mms"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMMSVcard is a contact card sent by MMS
+type MessagesMMSVcard struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Vcard          MessagesVcard     `json:"vcard"`
+	TTL            int               `json:"ttl,omitempty"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesMMSVcard) prepare() MessagesMessage {
+	m.MessageType = "vcard"
+	m.Channel = "mms"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMMSAudio is an audio file sent by MMS
+type MessagesMMSAudio struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Audio          MessagesAudio     `json:"audio"`
+	TTL            int               `json:"ttl,omitempty"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesMMSAudio) prepare() MessagesMessage {
+	m.MessageType = "audio"
+	m.Channel = "mms"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMMSVideo is a video file sent by MMS
+type MessagesMMSVideo struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Video          MessagesVideo     `json:"video"`
+	TTL            int               `json:"ttl,omitempty"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesMMSVideo) prepare() MessagesMessage {
+	m.MessageType = "video"
+	m.Channel = "mms"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+//--- WhatsApp ---
+
+// MessagesWhatsAppText is a text message sent by WhatsApp
+type MessagesWhatsAppText struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Text           string            `json:"text"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppText) prepare() MessagesMessage {
+	m.MessageType = "text"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppImage is an image sent by WhatsApp
+type MessagesWhatsAppImage struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Image          MessagesImage     `json:"image"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppImage) prepare() MessagesMessage {
+	m.MessageType = "image"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppAudio is an audio file sent by WhatsApp
+type MessagesWhatsAppAudio struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Audio          MessagesAudio     `json:"audio"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppAudio) prepare() MessagesMessage {
+	m.MessageType = "audio"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppVideo is a video file sent by WhatsApp
+type MessagesWhatsAppVideo struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Video          MessagesVideo     `json:"video"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppVideo) prepare() MessagesMessage {
+	m.MessageType = "video"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppFile is a file sent by WhatsApp
+type MessagesWhatsAppFile struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	File           MessagesFile      `json:"file"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppFile) prepare() MessagesMessage {
+	m.MessageType = "file"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppTemplate sends a pre-approved WhatsApp template. WhatsApp
+// settings are required for templates, the policy defaults to "deterministic"
+type MessagesWhatsAppTemplate struct {
+	MessageType    string                   `json:"message_type"`
+	Channel        string                   `json:"channel"`
+	To             string                   `json:"to"`
+	From           string                   `json:"from"`
+	Template       MessagesTemplate         `json:"template"`
+	WhatsApp       MessagesWhatsAppSettings `json:"whatsapp"`
+	ClientRef      string                   `json:"client_ref,omitempty"`
+	WebhookUrl     string                   `json:"webhook_url,omitempty"`
+	WebhookVersion string                   `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage        `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppTemplate) prepare() MessagesMessage {
+	m.MessageType = "template"
+	m.Channel = "whatsapp"
+	if m.WhatsApp.Policy == "" {
+		m.WhatsApp.Policy = "deterministic"
+	}
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppSticker is a sticker sent by WhatsApp
+type MessagesWhatsAppSticker struct {
+	MessageType    string            `json:"message_type"`
+	Channel        string            `json:"channel"`
+	To             string            `json:"to"`
+	From           string            `json:"from"`
+	Sticker        MessagesSticker   `json:"sticker"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	WebhookUrl     string            `json:"webhook_url,omitempty"`
+	WebhookVersion string            `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppSticker) prepare() MessagesMessage {
+	m.MessageType = "sticker"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesWhatsAppCustom sends any WhatsApp message object as-is, for the
+// message types that don't have their own struct here
+type MessagesWhatsAppCustom struct {
+	MessageType    string                 `json:"message_type"`
+	Channel        string                 `json:"channel"`
+	To             string                 `json:"to"`
+	From           string                 `json:"from"`
+	Custom         map[string]interface{} `json:"custom"`
+	ClientRef      string                 `json:"client_ref,omitempty"`
+	WebhookUrl     string                 `json:"webhook_url,omitempty"`
+	WebhookVersion string                 `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage      `json:"failover,omitempty"`
+}
+
+func (m MessagesWhatsAppCustom) prepare() MessagesMessage {
+	m.MessageType = "custom"
+	m.Channel = "whatsapp"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+//--- Facebook Messenger ---
+
+// MessagesMessengerText is a text message sent by Facebook Messenger
+type MessagesMessengerText struct {
+	MessageType    string                     `json:"message_type"`
+	Channel        string                     `json:"channel"`
+	To             string                     `json:"to"`
+	From           string                     `json:"from"`
+	Text           string                     `json:"text"`
+	Messenger      *MessagesMessengerSettings `json:"messenger,omitempty"`
+	ClientRef      string                     `json:"client_ref,omitempty"`
+	WebhookUrl     string                     `json:"webhook_url,omitempty"`
+	WebhookVersion string                     `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage          `json:"failover,omitempty"`
+}
+
+func (m MessagesMessengerText) prepare() MessagesMessage {
+	m.MessageType = "text"
+	m.Channel = "messenger"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMessengerImage is an image sent by Facebook Messenger
+type MessagesMessengerImage struct {
+	MessageType    string                     `json:"message_type"`
+	Channel        string                     `json:"channel"`
+	To             string                     `json:"to"`
+	From           string                     `json:"from"`
+	Image          MessagesImage              `json:"image"`
+	Messenger      *MessagesMessengerSettings `json:"messenger,omitempty"`
+	ClientRef      string                     `json:"client_ref,omitempty"`
+	WebhookUrl     string                     `json:"webhook_url,omitempty"`
+	WebhookVersion string                     `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage          `json:"failover,omitempty"`
+}
+
+func (m MessagesMessengerImage) prepare() MessagesMessage {
+	m.MessageType = "image"
+	m.Channel = "messenger"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMessengerAudio is an audio file sent by Facebook Messenger
+type MessagesMessengerAudio struct {
+	MessageType    string                     `json:"message_type"`
+	Channel        string                     `json:"channel"`
+	To             string                     `json:"to"`
+	From           string                     `json:"from"`
+	Audio          MessagesAudio              `json:"audio"`
+	Messenger      *MessagesMessengerSettings `json:"messenger,omitempty"`
+	ClientRef      string                     `json:"client_ref,omitempty"`
+	WebhookUrl     string                     `json:"webhook_url,omitempty"`
+	WebhookVersion string                     `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage          `json:"failover,omitempty"`
+}
+
+func (m MessagesMessengerAudio) prepare() MessagesMessage {
+	m.MessageType = "audio"
+	m.Channel = "messenger"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMessengerVideo is a video file sent by Facebook Messenger
+type MessagesMessengerVideo struct {
+	MessageType    string                     `json:"message_type"`
+	Channel        string                     `json:"channel"`
+	To             string                     `json:"to"`
+	From           string                     `json:"from"`
+	Video          MessagesVideo              `json:"video"`
+	Messenger      *MessagesMessengerSettings `json:"messenger,omitempty"`
+	ClientRef      string                     `json:"client_ref,omitempty"`
+	WebhookUrl     string                     `json:"webhook_url,omitempty"`
+	WebhookVersion string                     `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage          `json:"failover,omitempty"`
+}
+
+func (m MessagesMessengerVideo) prepare() MessagesMessage {
+	m.MessageType = "video"
+	m.Channel = "messenger"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesMessengerFile is a file sent by Facebook Messenger
+type MessagesMessengerFile struct {
+	MessageType    string                     `json:"message_type"`
+	Channel        string                     `json:"channel"`
+	To             string                     `json:"to"`
+	From           string                     `json:"from"`
+	File           MessagesFile               `json:"file"`
+	Messenger      *MessagesMessengerSettings `json:"messenger,omitempty"`
+	ClientRef      string                     `json:"client_ref,omitempty"`
+	WebhookUrl     string                     `json:"webhook_url,omitempty"`
+	WebhookVersion string                     `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage          `json:"failover,omitempty"`
+}
+
+func (m MessagesMessengerFile) prepare() MessagesMessage {
+	m.MessageType = "file"
+	m.Channel = "messenger"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+//--- Viber ---
+
+// MessagesViberText is a text message sent by Viber
+type MessagesViberText struct {
+	MessageType    string                 `json:"message_type"`
+	Channel        string                 `json:"channel"`
+	To             string                 `json:"to"`
+	From           string                 `json:"from"`
+	Text           string                 `json:"text"`
+	Viber          *MessagesViberSettings `json:"viber_service,omitempty"`
+	ClientRef      string                 `json:"client_ref,omitempty"`
+	WebhookUrl     string                 `json:"webhook_url,omitempty"`
+	WebhookVersion string                 `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage      `json:"failover,omitempty"`
+}
+
+func (m MessagesViberText) prepare() MessagesMessage {
+	m.MessageType = "text"
+	m.Channel = "viber_service"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesViberImage is an image sent by Viber
+type MessagesViberImage struct {
+	MessageType    string                 `json:"message_type"`
+	Channel        string                 `json:"channel"`
+	To             string                 `json:"to"`
+	From           string                 `json:"from"`
+	Image          MessagesImage          `json:"image"`
+	Viber          *MessagesViberSettings `json:"viber_service,omitempty"`
+	ClientRef      string                 `json:"client_ref,omitempty"`
+	WebhookUrl     string                 `json:"webhook_url,omitempty"`
+	WebhookVersion string                 `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage      `json:"failover,omitempty"`
+}
+
+func (m MessagesViberImage) prepare() MessagesMessage {
+	m.MessageType = "image"
+	m.Channel = "viber_service"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesViberVideo is a video file sent by Viber
+type MessagesViberVideo struct {
+	MessageType    string                 `json:"message_type"`
+	Channel        string                 `json:"channel"`
+	To             string                 `json:"to"`
+	From           string                 `json:"from"`
+	Video          MessagesVideo          `json:"video"`
+	Viber          *MessagesViberSettings `json:"viber_service,omitempty"`
+	ClientRef      string                 `json:"client_ref,omitempty"`
+	WebhookUrl     string                 `json:"webhook_url,omitempty"`
+	WebhookVersion string                 `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage      `json:"failover,omitempty"`
+}
+
+func (m MessagesViberVideo) prepare() MessagesMessage {
+	m.MessageType = "video"
+	m.Channel = "viber_service"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+// MessagesViberFile is a file sent by Viber
+type MessagesViberFile struct {
+	MessageType    string                 `json:"message_type"`
+	Channel        string                 `json:"channel"`
+	To             string                 `json:"to"`
+	From           string                 `json:"from"`
+	File           MessagesFile           `json:"file"`
+	Viber          *MessagesViberSettings `json:"viber_service,omitempty"`
+	ClientRef      string                 `json:"client_ref,omitempty"`
+	WebhookUrl     string                 `json:"webhook_url,omitempty"`
+	WebhookVersion string                 `json:"webhook_version,omitempty"`
+	Failover       []MessagesMessage      `json:"failover,omitempty"`
+}
+
+func (m MessagesViberFile) prepare() MessagesMessage {
+	m.MessageType = "file"
+	m.Channel = "viber_service"
+	m.Failover = prepareFailover(m.Failover)
+	return m
+}
+
+//--- Webhooks ---
+
+// MessagesProfile is the sender's profile, where the channel supplies one
+type MessagesProfile struct {
+	Name string `json:"name,omitempty"`
+}
+
+// MessagesUsage is the cost of a message
+type MessagesUsage struct {
+	Currency string `json:"currency,omitempty"`
+	Price    string `json:"price,omitempty"`
+}
+
+// MessagesInboundContext is set when the inbound message is a reply
+type MessagesInboundContext struct {
+	MessageUuid string `json:"message_uuid,omitempty"`
+	MessageFrom string `json:"message_from,omitempty"`
+}
+
+// MessagesInboundSMS holds the SMS-specific inbound details
+type MessagesInboundSMS struct {
+	NumMessages string `json:"num_messages,omitempty"`
+}
+
+// MessagesLocation is a location shared by WhatsApp
+type MessagesLocation struct {
+	Lat     float64 `json:"lat"`
+	Long    float64 `json:"long"`
+	Name    string  `json:"name,omitempty"`
+	Address string  `json:"address,omitempty"`
+}
+
+// MessagesReply is the option picked from a WhatsApp interactive message
+type MessagesReply struct {
+	Id          string `json:"id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// MessagesInbound is the data sent to the inbound webhook when a message
+// arrives. Which content field is set depends on the MessageType
+type MessagesInbound struct {
+	Channel     string                  `json:"channel"`
+	MessageUuid string                  `json:"message_uuid"`
+	To          string                  `json:"to"`
+	From        string                  `json:"from"`
+	Timestamp   time.Time               `json:"timestamp"`
+	MessageType string                  `json:"message_type"`
+	Text        string                  `json:"text,omitempty"`
+	Image       *MessagesImage          `json:"image,omitempty"`
+	Audio       *MessagesAudio          `json:"audio,omitempty"`
+	Video       *MessagesVideo          `json:"video,omitempty"`
+	File        *MessagesFile           `json:"file,omitempty"`
+	Vcard       *MessagesVcard          `json:"vcard,omitempty"`
+	Sticker     *MessagesSticker        `json:"sticker,omitempty"`
+	Location    *MessagesLocation       `json:"location,omitempty"`
+	Reply       *MessagesReply          `json:"reply,omitempty"`
+	Profile     *MessagesProfile        `json:"profile,omitempty"`
+	Context     *MessagesInboundContext `json:"context,omitempty"`
+	SMS         *MessagesInboundSMS     `json:"sms,omitempty"`
+	Usage       *MessagesUsage          `json:"usage,omitempty"`
+}
+
+// MessagesStatusError explains why a message failed. Title holds the
+// numeric error code
+type MessagesStatusError struct {
+	Type     string      `json:"type,omitempty"`
+	Title    json.Number `json:"title,omitempty"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+}
+
+// MessagesStatusDestination is the network the message was delivered to
+type MessagesStatusDestination struct {
+	NetworkCode string `json:"network_code,omitempty"`
+}
+
+// MessagesStatusWorkflow is set when the message was part of a failover
+type MessagesStatusWorkflow struct {
+	WorkflowId  string `json:"workflow_id,omitempty"`
+	ItemNumber  string `json:"item_number,omitempty"`
+	ItemsNumber string `json:"items_number,omitempty"`
+}
+
+// MessagesStatus is the data sent to the status webhook as a message
+// progresses: submitted, delivered, read, rejected or undeliverable
+type MessagesStatus struct {
+	MessageUuid string                     `json:"message_uuid"`
+	To          string                     `json:"to"`
+	From        string                     `json:"from"`
+	Timestamp   time.Time                  `json:"timestamp"`
+	Status      string                     `json:"status"`
+	Channel     string                     `json:"channel"`
+	ClientRef   string                     `json:"client_ref,omitempty"`
+	Error       *MessagesStatusError       `json:"error,omitempty"`
+	Usage       *MessagesUsage             `json:"usage,omitempty"`
+	Destination *MessagesStatusDestination `json:"destination,omitempty"`
+	Workflow    *MessagesStatusWorkflow    `json:"workflow,omitempty"`
+}
+
+// ParseMessagesInbound reads the body of an inbound message webhook
+func ParseMessagesInbound(body io.Reader) (MessagesInbound, error) {
+	var inbound MessagesInbound
+	err := json.NewDecoder(body).Decode(&inbound)
+	return inbound, err
+}
+
+// ParseMessagesStatus reads the body of a message status webhook
+func ParseMessagesStatus(body io.Reader) (MessagesStatus, error) {
+	var status MessagesStatus
+	err := json.NewDecoder(body).Decode(&status)
+	return status, err
+}
@@ -0,0 +1,220 @@
+package vonage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestMessagesNewClient(*testing.T) {
+	auth := CreateAuthFromKeySecret("123", "456")
+	NewMessagesClient(auth)
+}
+
+func TestMessagesSendSMS(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	var username string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/messages",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			username, _, _ = req.BasicAuth()
+			resp := httpmock.NewStringResponse(202, `
+{
+  "message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab"
+}
+	`,
+			)
+
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewMessagesClient(auth)
+	response, _, _ := client.Send(MessagesSMSText{To: "447700900000", From: "447700900001", Text: "Hello"})
+
+	if response.MessageUuid != "aaaaaaaa-bbbb-cccc-dddd-0123456789ab" {
+		t.Errorf("Messages send SMS failed")
+	}
+
+	if username != "12345678" {
+		t.Errorf("Messages send SMS did not use basic auth")
+	}
+
+	if strings.TrimSpace(body) != `{"message_type":"text","channel":"sms","to":"447700900000","from":"447700900001","text":"Hello"}` {
+		t.Errorf("Unexpected JSON format for: SMS text: %s", body)
+	}
+}
+
+func TestMessagesSendWithFailover(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	var authHeader string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/messages",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			authHeader = req.Header.Get("Authorization")
+			resp := httpmock.NewStringResponse(202, `
+{
+  "message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab",
+  "workflow_id": "3TcNjguHxr2vcCRMr1tRsM"
+}
+	`,
+			)
+
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth := &JWTAuth{JWT: "a.b.c"}
+	client := NewMessagesClient(auth)
+	message := MessagesWhatsAppImage{
+		To:       "447700900000",
+		From:     "447700900001",
+		Image:    MessagesImage{Url: "https://example.com/cat.jpg", Caption: "Cat"},
+		Failover: []MessagesMessage{MessagesSMSText{To: "447700900000", From: "Vonage", Text: "A cat"}},
+	}
+	response, _, _ := client.Send(message)
+
+	if response.WorkflowId != "3TcNjguHxr2vcCRMr1tRsM" {
+		t.Errorf("Messages send with failover failed")
+	}
+
+	if authHeader != "Bearer a.b.c" {
+		t.Errorf("Messages send did not use JWT auth")
+	}
+
+	expected := `{"message_type":"image","channel":"whatsapp","to":"447700900000","from":"447700900001","image":{"url":"https://example.com/cat.jpg","caption":"Cat"},"failover":[{"message_type":"text","channel":"sms","to":"447700900000","from":"Vonage","text":"A cat"}]}`
+	if strings.TrimSpace(body) != expected {
+		t.Errorf("Unexpected JSON format for: WhatsApp image with failover: %s", body)
+	}
+}
+
+func TestMessagesSendFail(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/messages",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(422, `
+{
+  "type": "https://developer.nexmo.com/api-errors/messages-olympus#1120",
+  "title": "Invalid sender",
+  "detail": "The from parameter is invalid for the given channel.",
+  "instance": "bf0ca0bf927b3b52e3cb03217e1a1ddf"
+}
+	`,
+			)
+
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewMessagesClient(auth)
+	_, errResp, err := client.Send(MessagesViberText{To: "447700900000", From: "nope", Text: "Hello"})
+
+	if err == nil {
+		t.Errorf("Messages send failure did not return an error")
+	}
+
+	if errResp.Title != "Invalid sender" {
+		t.Errorf("Messages send failure did not return the error details")
+	}
+}
+
+func TestMessagesWhatsAppTemplate(t *testing.T) {
+	message := MessagesWhatsAppTemplate{
+		To:       "447700900000",
+		From:     "447700900001",
+		Template: MessagesTemplate{Name: "whatsapp:hsm:technology:nexmo:verify", Parameters: []string{"Vonage", "1234"}},
+		WhatsApp: MessagesWhatsAppSettings{Locale: "en-GB"},
+	}
+
+	j, _ := json.Marshal(message.prepare())
+	expected := `{"message_type":"template","channel":"whatsapp","to":"447700900000","from":"447700900001","template":{"name":"whatsapp:hsm:technology:nexmo:verify","parameters":["Vonage","1234"]},"whatsapp":{"policy":"deterministic","locale":"en-GB"}}`
+	if string(j) != expected {
+		t.Errorf("Unexpected JSON format for: WhatsApp template: %s", j)
+	}
+}
+
+func TestMessagesParseInbound(t *testing.T) {
+	body := strings.NewReader(`
+{
+  "channel": "whatsapp",
+  "message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab",
+  "to": "447700900000",
+  "from": "447700900001",
+  "timestamp": "2020-01-01T14:00:00.000Z",
+  "profile": {
+    "name": "Jane Smith"
+  },
+  "message_type": "image",
+  "image": {
+    "url": "https://example.com/image.jpg"
+  }
+}`)
+
+	inbound, err := ParseMessagesInbound(body)
+	if err != nil {
+		t.Errorf("Messages inbound parse failed: %s", err)
+	}
+
+	if inbound.Image.Url != "https://example.com/image.jpg" || inbound.Profile.Name != "Jane Smith" {
+		t.Errorf("Messages inbound content not parsed")
+	}
+
+	if inbound.Timestamp.Hour() != 14 {
+		t.Errorf("Messages inbound timestamp not parsed")
+	}
+}
+
+func TestMessagesParseStatus(t *testing.T) {
+	body := strings.NewReader(`
+{
+  "message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab",
+  "to": "447700900000",
+  "from": "447700900001",
+  "timestamp": "2020-01-01T14:00:00.000Z",
+  "status": "rejected",
+  "error": {
+    "type": "https://developer.nexmo.com/api-errors/messages-olympus#1000",
+    "title": 1000,
+    "detail": "Throttled - You have exceeded the submission capacity allowed on this account.",
+    "instance": "bf0ca0bf927b3b52e3cb03217e1a1ddf"
+  },
+  "usage": {
+    "currency": "EUR",
+    "price": "0.0333"
+  },
+  "client_ref": "abc123",
+  "channel": "sms"
+}`)
+
+	status, err := ParseMessagesStatus(body)
+	if err != nil {
+		t.Errorf("Messages status parse failed: %s", err)
+	}
+
+	if status.Status != "rejected" || status.Error.Title.String() != "1000" {
+		t.Errorf("Messages status error not parsed")
+	}
+
+	if status.Usage.Price != "0.0333" {
+		t.Errorf("Messages status usage not parsed")
+	}
+}
@@ -0,0 +1,562 @@
+package vonage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// WhatsApp enforces these limits, check them before sending rather than
+// waiting for the message to be rejected
+const (
+	whatsAppMaxHeaderText     = 60
+	whatsAppMaxBodyText       = 1024
+	whatsAppMaxFooterText     = 60
+	whatsAppMaxListButton     = 20
+	whatsAppMaxListSections   = 10
+	whatsAppMaxListRows       = 10
+	whatsAppMaxSectionTitle   = 24
+	whatsAppMaxRowId          = 200
+	whatsAppMaxRowTitle       = 24
+	whatsAppMaxRowDescription = 72
+	whatsAppMaxReplyButtons   = 3
+	whatsAppMaxReplyId        = 256
+	whatsAppMaxReplyTitle     = 20
+	whatsAppMaxQuickReplies   = 3
+	whatsAppMaxUrlButtons     = 2
+)
+
+// whatsAppLocale matches codes like "en", "en_GB", "en-GB" and "fil"
+var whatsAppLocale = regexp.MustCompile(`^[a-z]{2,3}([_-][A-Z]{2})?$`)
+
+// validateWhatsAppSettings checks the locale and policy used by templates
+func validateWhatsAppSettings(locale string, policy string) error {
+	if locale == "" {
+		return errors.New("WhatsApp templates need a locale, such as en_GB")
+	}
+	if !whatsAppLocale.MatchString(locale) {
+		return fmt.Errorf("WhatsApp locale %q is not valid, use a code such as en or en_GB", locale)
+	}
+	if policy != "" && policy != "deterministic" {
+		return fmt.Errorf("WhatsApp policy %q is not supported, use deterministic", policy)
+	}
+	return nil
+}
+
+// validateLength checks a piece of text against a WhatsApp character limit
+func validateLength(field string, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return fmt.Errorf("WhatsApp %s must be %d characters or fewer", field, max)
+	}
+	return nil
+}
+
+// Validate checks the WhatsApp settings on a simple template message
+func (m MessagesWhatsAppTemplate) Validate() error {
+	if m.Template.Name == "" {
+		return errors.New("WhatsApp templates need a name")
+	}
+	return validateWhatsAppSettings(m.WhatsApp.Locale, m.WhatsApp.Policy)
+}
+
+//--- Templates with components ---
+
+// WhatsAppMedia is a link to an image, video or document
+type WhatsAppMedia struct {
+	Link     string `json:"link"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// WhatsAppCurrency is a localised currency amount, Amount1000 is the
+// amount multiplied by 1000
+type WhatsAppCurrency struct {
+	FallbackValue string `json:"fallback_value"`
+	Code          string `json:"code"`
+	Amount1000    int64  `json:"amount_1000"`
+}
+
+// WhatsAppDateTime is a localised date and time
+type WhatsAppDateTime struct {
+	FallbackValue string `json:"fallback_value"`
+}
+
+// WhatsAppTemplateParameter is one value substituted into a template. Set
+// one of the value fields, the Type is worked out from which one is set
+type WhatsAppTemplateParameter struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Image    *WhatsAppMedia    `json:"image,omitempty"`
+	Video    *WhatsAppMedia    `json:"video,omitempty"`
+	Document *WhatsAppMedia    `json:"document,omitempty"`
+	Currency *WhatsAppCurrency `json:"currency,omitempty"`
+	DateTime *WhatsAppDateTime `json:"date_time,omitempty"`
+}
+
+// prepare for WhatsAppTemplateParameter sets the type
+func (p WhatsAppTemplateParameter) prepare() WhatsAppTemplateParameter {
+	switch {
+	case p.Image != nil:
+		p.Type = "image"
+	case p.Video != nil:
+		p.Type = "video"
+	case p.Document != nil:
+		p.Type = "document"
+	case p.Currency != nil:
+		p.Type = "currency"
+	case p.DateTime != nil:
+		p.Type = "date_time"
+	default:
+		p.Type = "text"
+	}
+	return p
+}
+
+// WhatsAppTemplateButton fills in a button on the template. SubType is
+// either "quick_reply", which needs a Payload, or "url", which needs the
+// Text to add to the end of the button's URL
+type WhatsAppTemplateButton struct {
+	SubType string
+	Payload string
+	Text    string
+}
+
+// WhatsAppTemplate is a WhatsApp template with header, body and button
+// components. Policy defaults to "deterministic"
+type WhatsAppTemplate struct {
+	Namespace string
+	Name      string
+	Locale    string
+	Policy    string
+	Header    *WhatsAppTemplateParameter
+	Body      []WhatsAppTemplateParameter
+	Buttons   []WhatsAppTemplateButton
+}
+
+type whatsAppTemplateLanguage struct {
+	Policy string `json:"policy"`
+	Code   string `json:"code"`
+}
+
+type whatsAppTemplateButtonParameter struct {
+	Type    string `json:"type"`
+	Payload string `json:"payload,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+type whatsAppTemplateComponent struct {
+	Type       string        `json:"type"`
+	SubType    string        `json:"sub_type,omitempty"`
+	Index      string        `json:"index,omitempty"`
+	Parameters []interface{} `json:"parameters"`
+}
+
+type whatsAppTemplateJSON struct {
+	Namespace  string                      `json:"namespace,omitempty"`
+	Name       string                      `json:"name"`
+	Language   whatsAppTemplateLanguage    `json:"language"`
+	Components []whatsAppTemplateComponent `json:"components,omitempty"`
+}
+
+// MarshalJSON assembles the nested components structure that WhatsApp expects
+func (t WhatsAppTemplate) MarshalJSON() ([]byte, error) {
+	template := whatsAppTemplateJSON{Namespace: t.Namespace, Name: t.Name}
+	template.Language = whatsAppTemplateLanguage{Policy: t.Policy, Code: t.Locale}
+	if template.Language.Policy == "" {
+		template.Language.Policy = "deterministic"
+	}
+
+	if t.Header != nil {
+		header := whatsAppTemplateComponent{Type: "header", Parameters: []interface{}{t.Header.prepare()}}
+		template.Components = append(template.Components, header)
+	}
+
+	if len(t.Body) > 0 {
+		body := whatsAppTemplateComponent{Type: "body"}
+		for _, param := range t.Body {
+			body.Parameters = append(body.Parameters, param.prepare())
+		}
+		template.Components = append(template.Components, body)
+	}
+
+	for i, button := range t.Buttons {
+		component := whatsAppTemplateComponent{Type: "button", SubType: button.SubType, Index: strconv.Itoa(i)}
+		if button.SubType == "url" {
+			component.Parameters = []interface{}{whatsAppTemplateButtonParameter{Type: "text", Text: button.Text}}
+		} else {
+			component.Parameters = []interface{}{whatsAppTemplateButtonParameter{Type: "payload", Payload: button.Payload}}
+		}
+		template.Components = append(template.Components, component)
+	}
+
+	return json.Marshal(template)
+}
+
+// Validate checks the template against the WhatsApp limits
+func (t WhatsAppTemplate) Validate() error {
+	if t.Name == "" {
+		return errors.New("WhatsApp templates need a name")
+	}
+	if err := validateWhatsAppSettings(t.Locale, t.Policy); err != nil {
+		return err
+	}
+
+	if t.Header != nil {
+		header := t.Header.prepare()
+		if header.Type == "currency" || header.Type == "date_time" {
+			return errors.New("WhatsApp template header must be text, image, video or document")
+		}
+		if err := validateLength("template header text", header.Text, whatsAppMaxHeaderText); err != nil {
+			return err
+		}
+	}
+
+	for i, param := range t.Body {
+		param = param.prepare()
+		if param.Type == "text" && param.Text == "" {
+			return fmt.Errorf("WhatsApp template body parameter %d has no value", i)
+		}
+		if err := validateLength("template body parameter", param.Text, whatsAppMaxBodyText); err != nil {
+			return err
+		}
+	}
+
+	quickReplies := 0
+	urls := 0
+	for i, button := range t.Buttons {
+		switch button.SubType {
+		case "quick_reply":
+			quickReplies++
+			if button.Payload == "" {
+				return fmt.Errorf("WhatsApp template button %d needs a payload", i)
+			}
+		case "url":
+			urls++
+			if button.Text == "" {
+				return fmt.Errorf("WhatsApp template button %d needs text for the URL", i)
+			}
+		default:
+			return fmt.Errorf("WhatsApp template button %d has unknown sub type %q, use quick_reply or url", i, button.SubType)
+		}
+	}
+	if quickReplies > 0 && urls > 0 {
+		return errors.New("WhatsApp templates cannot mix quick_reply and url buttons")
+	}
+	if quickReplies > whatsAppMaxQuickReplies {
+		return fmt.Errorf("WhatsApp templates can have at most %d quick_reply buttons", whatsAppMaxQuickReplies)
+	}
+	if urls > whatsAppMaxUrlButtons {
+		return fmt.Errorf("WhatsApp templates can have at most %d url buttons", whatsAppMaxUrlButtons)
+	}
+
+	return nil
+}
+
+//--- Interactive messages ---
+
+// WhatsAppInteractiveHeader is the header of an interactive message, set
+// one of the fields. List messages only support a text header
+type WhatsAppInteractiveHeader struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text,omitempty"`
+	Image    *WhatsAppMedia `json:"image,omitempty"`
+	Video    *WhatsAppMedia `json:"video,omitempty"`
+	Document *WhatsAppMedia `json:"document,omitempty"`
+}
+
+// prepare for WhatsAppInteractiveHeader sets the type
+func (h WhatsAppInteractiveHeader) prepare() WhatsAppInteractiveHeader {
+	switch {
+	case h.Image != nil:
+		h.Type = "image"
+	case h.Video != nil:
+		h.Type = "video"
+	case h.Document != nil:
+		h.Type = "document"
+	default:
+		h.Type = "text"
+	}
+	return h
+}
+
+// WhatsAppListRow is one option the user can pick from a list
+type WhatsAppListRow struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// WhatsAppListSection groups rows in a list, the title is required when
+// there is more than one section
+type WhatsAppListSection struct {
+	Title string            `json:"title,omitempty"`
+	Rows  []WhatsAppListRow `json:"rows"`
+}
+
+// WhatsAppList is an interactive message that opens a list of options when
+// the user taps the Button
+type WhatsAppList struct {
+	Header   *WhatsAppInteractiveHeader
+	Body     string
+	Footer   string
+	Button   string
+	Sections []WhatsAppListSection
+}
+
+// WhatsAppReplyButton is a button the user can tap to reply
+type WhatsAppReplyButton struct {
+	Id    string
+	Title string
+}
+
+// WhatsAppReplyButtons is an interactive message with up to three buttons
+type WhatsAppReplyButtons struct {
+	Header  *WhatsAppInteractiveHeader
+	Body    string
+	Footer  string
+	Buttons []WhatsAppReplyButton
+}
+
+type whatsAppText struct {
+	Text string `json:"text"`
+}
+
+type whatsAppReply struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type whatsAppReplyButtonJSON struct {
+	Type  string        `json:"type"`
+	Reply whatsAppReply `json:"reply"`
+}
+
+type whatsAppInteractiveAction struct {
+	Button   string                    `json:"button,omitempty"`
+	Sections []WhatsAppListSection     `json:"sections,omitempty"`
+	Buttons  []whatsAppReplyButtonJSON `json:"buttons,omitempty"`
+}
+
+type whatsAppInteractiveJSON struct {
+	Type   string                     `json:"type"`
+	Header *WhatsAppInteractiveHeader `json:"header,omitempty"`
+	Body   whatsAppText               `json:"body"`
+	Footer *whatsAppText              `json:"footer,omitempty"`
+	Action whatsAppInteractiveAction  `json:"action"`
+}
+
+// newWhatsAppInteractive fills in the parts that lists and buttons share
+func newWhatsAppInteractive(interactiveType string, header *WhatsAppInteractiveHeader, body string, footer string) whatsAppInteractiveJSON {
+	interactive := whatsAppInteractiveJSON{Type: interactiveType, Body: whatsAppText{Text: body}}
+	if header != nil {
+		prepared := header.prepare()
+		interactive.Header = &prepared
+	}
+	if footer != "" {
+		interactive.Footer = &whatsAppText{Text: footer}
+	}
+	return interactive
+}
+
+// MarshalJSON assembles the interactive list structure
+func (l WhatsAppList) MarshalJSON() ([]byte, error) {
+	interactive := newWhatsAppInteractive("list", l.Header, l.Body, l.Footer)
+	interactive.Action = whatsAppInteractiveAction{Button: l.Button, Sections: l.Sections}
+	return json.Marshal(interactive)
+}
+
+// MarshalJSON assembles the interactive reply buttons structure
+func (b WhatsAppReplyButtons) MarshalJSON() ([]byte, error) {
+	interactive := newWhatsAppInteractive("button", b.Header, b.Body, b.Footer)
+	for _, button := range b.Buttons {
+		interactive.Action.Buttons = append(interactive.Action.Buttons, whatsAppReplyButtonJSON{
+			Type:  "reply",
+			Reply: whatsAppReply{Id: button.Id, Title: button.Title},
+		})
+	}
+	return json.Marshal(interactive)
+}
+
+// validateWhatsAppInteractive checks the parts that lists and buttons share
+func validateWhatsAppInteractive(header *WhatsAppInteractiveHeader, body string, footer string) error {
+	if header != nil {
+		if err := validateLength("header text", header.Text, whatsAppMaxHeaderText); err != nil {
+			return err
+		}
+	}
+	if body == "" {
+		return errors.New("WhatsApp interactive messages need body text")
+	}
+	if err := validateLength("body text", body, whatsAppMaxBodyText); err != nil {
+		return err
+	}
+	return validateLength("footer text", footer, whatsAppMaxFooterText)
+}
+
+// Validate checks the list against the WhatsApp limits
+func (l WhatsAppList) Validate() error {
+	if l.Header != nil && l.Header.prepare().Type != "text" {
+		return errors.New("WhatsApp list messages only support a text header")
+	}
+	if err := validateWhatsAppInteractive(l.Header, l.Body, l.Footer); err != nil {
+		return err
+	}
+	if l.Button == "" {
+		return errors.New("WhatsApp list messages need button text")
+	}
+	if err := validateLength("list button text", l.Button, whatsAppMaxListButton); err != nil {
+		return err
+	}
+
+	if len(l.Sections) == 0 {
+		return errors.New("WhatsApp list messages need at least one section")
+	}
+	if len(l.Sections) > whatsAppMaxListSections {
+		return fmt.Errorf("WhatsApp list messages can have at most %d sections", whatsAppMaxListSections)
+	}
+
+	rows := 0
+	ids := make(map[string]bool)
+	for i, section := range l.Sections {
+		if len(l.Sections) > 1 && section.Title == "" {
+			return fmt.Errorf("WhatsApp list section %d needs a title when there is more than one section", i)
+		}
+		if err := validateLength("section title", section.Title, whatsAppMaxSectionTitle); err != nil {
+			return err
+		}
+		if len(section.Rows) == 0 {
+			return fmt.Errorf("WhatsApp list section %d has no rows", i)
+		}
+		for _, row := range section.Rows {
+			rows++
+			if row.Id == "" || row.Title == "" {
+				return fmt.Errorf("WhatsApp list rows in section %d need an id and a title", i)
+			}
+			if ids[row.Id] {
+				return fmt.Errorf("WhatsApp list row id %q is used more than once", row.Id)
+			}
+			ids[row.Id] = true
+			if err := validateLength("row id", row.Id, whatsAppMaxRowId); err != nil {
+				return err
+			}
+			if err := validateLength("row title", row.Title, whatsAppMaxRowTitle); err != nil {
+				return err
+			}
+			if err := validateLength("row description", row.Description, whatsAppMaxRowDescription); err != nil {
+				return err
+			}
+		}
+	}
+	if rows > whatsAppMaxListRows {
+		return fmt.Errorf("WhatsApp list messages can have at most %d rows in total", whatsAppMaxListRows)
+	}
+
+	return nil
+}
+
+// Validate checks the reply buttons against the WhatsApp limits
+func (b WhatsAppReplyButtons) Validate() error {
+	if err := validateWhatsAppInteractive(b.Header, b.Body, b.Footer); err != nil {
+		return err
+	}
+
+	if len(b.Buttons) == 0 {
+		return errors.New("WhatsApp reply button messages need at least one button")
+	}
+	if len(b.Buttons) > whatsAppMaxReplyButtons {
+		return fmt.Errorf("WhatsApp reply button messages can have at most %d buttons", whatsAppMaxReplyButtons)
+	}
+
+	ids := make(map[string]bool)
+	titles := make(map[string]bool)
+	for i, button := range b.Buttons {
+		if button.Id == "" || button.Title == "" {
+			return fmt.Errorf("WhatsApp reply button %d needs an id and a title", i)
+		}
+		if ids[button.Id] || titles[button.Title] {
+			return fmt.Errorf("WhatsApp reply button %d repeats the id or title of another button", i)
+		}
+		ids[button.Id] = true
+		titles[button.Title] = true
+		if err := validateLength("reply button id", button.Id, whatsAppMaxReplyId); err != nil {
+			return err
+		}
+		if err := validateLength("reply button title", button.Title, whatsAppMaxReplyTitle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//--- Messages ---
+
+// MessagesWhatsAppCustomTemplate sends a WhatsApp template with components,
+// which the Messages API accepts as a custom message
+type MessagesWhatsAppCustomTemplate struct {
+	To             string
+	From           string
+	Template       WhatsAppTemplate
+	ClientRef      string
+	WebhookUrl     string
+	WebhookVersion string
+	Failover       []MessagesMessage
+}
+
+func (m MessagesWhatsAppCustomTemplate) prepare() MessagesMessage {
+	custom := map[string]interface{}{"type": "template", "template": m.Template}
+	return MessagesWhatsAppCustom{To: m.To, From: m.From, Custom: custom, ClientRef: m.ClientRef,
+		WebhookUrl: m.WebhookUrl, WebhookVersion: m.WebhookVersion, Failover: m.Failover}.prepare()
+}
+
+// Validate checks the template before sending
+func (m MessagesWhatsAppCustomTemplate) Validate() error {
+	return m.Template.Validate()
+}
+
+// MessagesWhatsAppList sends a WhatsApp interactive list message
+type MessagesWhatsAppList struct {
+	To             string
+	From           string
+	List           WhatsAppList
+	ClientRef      string
+	WebhookUrl     string
+	WebhookVersion string
+	Failover       []MessagesMessage
+}
+
+func (m MessagesWhatsAppList) prepare() MessagesMessage {
+	custom := map[string]interface{}{"type": "interactive", "interactive": m.List}
+	return MessagesWhatsAppCustom{To: m.To, From: m.From, Custom: custom, ClientRef: m.ClientRef,
+		WebhookUrl: m.WebhookUrl, WebhookVersion: m.WebhookVersion, Failover: m.Failover}.prepare()
+}
+
+// Validate checks the list before sending
+func (m MessagesWhatsAppList) Validate() error {
+	return m.List.Validate()
+}
+
+// MessagesWhatsAppReplyButtons sends a WhatsApp interactive reply buttons message
+type MessagesWhatsAppReplyButtons struct {
+	To             string
+	From           string
+	Buttons        WhatsAppReplyButtons
+	ClientRef      string
+	WebhookUrl     string
+	WebhookVersion string
+	Failover       []MessagesMessage
+}
+
+func (m MessagesWhatsAppReplyButtons) prepare() MessagesMessage {
+	custom := map[string]interface{}{"type": "interactive", "interactive": m.Buttons}
+	return MessagesWhatsAppCustom{To: m.To, From: m.From, Custom: custom, ClientRef: m.ClientRef,
+		WebhookUrl: m.WebhookUrl, WebhookVersion: m.WebhookVersion, Failover: m.Failover}.prepare()
+}
+
+// Validate checks the buttons before sending
+func (m MessagesWhatsAppReplyButtons) Validate() error {
+	return m.Buttons.Validate()
+}
@@ -0,0 +1,188 @@
+package vonage
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestWhatsAppCustomTemplate(t *testing.T) {
+	message := MessagesWhatsAppCustomTemplate{
+		To:   "447700900000",
+		From: "447700900001",
+		Template: WhatsAppTemplate{
+			Name:   "order_update",
+			Locale: "en_GB",
+			Header: &WhatsAppTemplateParameter{Image: &WhatsAppMedia{Link: "https://example.com/parcel.jpg"}},
+			Body:   []WhatsAppTemplateParameter{{Text: "Jane"}, {Text: "ABC123"}},
+			Buttons: []WhatsAppTemplateButton{
+				{SubType: "quick_reply", Payload: "track"},
+				{SubType: "quick_reply", Payload: "cancel"},
+			},
+		},
+	}
+
+	if err := message.Validate(); err != nil {
+		t.Errorf("WhatsApp template should be valid: %s", err)
+	}
+
+	j, _ := json.Marshal(message.prepare())
+	expected := `{"message_type":"custom","channel":"whatsapp","to":"447700900000","from":"447700900001","custom":{"template":{"name":"order_update","language":{"policy":"deterministic","code":"en_GB"},"components":[{"type":"header","parameters":[{"type":"image","image":{"link":"https://example.com/parcel.jpg"}}]},{"type":"body","parameters":[{"type":"text","text":"Jane"},{"type":"text","text":"ABC123"}]},{"type":"button","sub_type":"quick_reply","index":"0","parameters":[{"type":"payload","payload":"track"}]},{"type":"button","sub_type":"quick_reply","index":"1","parameters":[{"type":"payload","payload":"cancel"}]}]},"type":"template"}}`
+	if string(j) != expected {
+		t.Errorf("Unexpected JSON format for: WhatsApp custom template: %s", j)
+	}
+}
+
+func TestWhatsAppTemplateValidation(t *testing.T) {
+	noLocale := WhatsAppTemplate{Name: "order_update"}
+	if noLocale.Validate() == nil {
+		t.Errorf("WhatsApp template without a locale should be invalid")
+	}
+
+	for _, locale := range []string{"en", "en_GB", "en-GB", "fil"} {
+		template := MessagesWhatsAppTemplate{Template: MessagesTemplate{Name: "verify"}, WhatsApp: MessagesWhatsAppSettings{Locale: locale}}
+		if err := template.Validate(); err != nil {
+			t.Errorf("WhatsApp locale %q should be valid: %s", locale, err)
+		}
+	}
+
+	badLocale := WhatsAppTemplate{Name: "order_update", Locale: "english"}
+	if badLocale.Validate() == nil {
+		t.Errorf("WhatsApp template with a bad locale should be invalid")
+	}
+
+	mixed := WhatsAppTemplate{Name: "order_update", Locale: "en", Buttons: []WhatsAppTemplateButton{
+		{SubType: "quick_reply", Payload: "yes"},
+		{SubType: "url", Text: "abc"},
+	}}
+	if mixed.Validate() == nil {
+		t.Errorf("WhatsApp template mixing button types should be invalid")
+	}
+
+	simple := MessagesWhatsAppTemplate{Template: MessagesTemplate{Name: "verify"}, WhatsApp: MessagesWhatsAppSettings{Locale: "en_GB", Policy: "fallback"}}
+	if simple.Validate() == nil {
+		t.Errorf("WhatsApp template with fallback policy should be invalid")
+	}
+}
+
+func TestWhatsAppList(t *testing.T) {
+	message := MessagesWhatsAppList{
+		To:   "447700900000",
+		From: "447700900001",
+		List: WhatsAppList{
+			Header: &WhatsAppInteractiveHeader{Text: "Opening hours"},
+			Body:   "Which store?",
+			Button: "Choose",
+			Sections: []WhatsAppListSection{
+				{Rows: []WhatsAppListRow{{Id: "ldn", Title: "London", Description: "Oxford Street"}}},
+			},
+		},
+	}
+
+	if err := message.Validate(); err != nil {
+		t.Errorf("WhatsApp list should be valid: %s", err)
+	}
+
+	j, _ := json.Marshal(message.prepare())
+	expected := `"custom":{"interactive":{"type":"list","header":{"type":"text","text":"Opening hours"},"body":{"text":"Which store?"},"action":{"button":"Choose","sections":[{"rows":[{"id":"ldn","title":"London","description":"Oxford Street"}]}]}},"type":"interactive"}`
+	if !strings.Contains(string(j), expected) {
+		t.Errorf("Unexpected JSON format for: WhatsApp list: %s", j)
+	}
+}
+
+func TestWhatsAppListValidation(t *testing.T) {
+	rows := []WhatsAppListRow{}
+	for _, id := range strings.Split("a b c d e f g h i j k", " ") {
+		rows = append(rows, WhatsAppListRow{Id: id, Title: id})
+	}
+	tooMany := WhatsAppList{Body: "Pick one", Button: "Choose", Sections: []WhatsAppListSection{{Rows: rows}}}
+	if tooMany.Validate() == nil {
+		t.Errorf("WhatsApp list with 11 rows should be invalid")
+	}
+
+	untitled := WhatsAppList{Body: "Pick one", Button: "Choose", Sections: []WhatsAppListSection{
+		{Title: "First", Rows: []WhatsAppListRow{{Id: "1", Title: "One"}}},
+		{Rows: []WhatsAppListRow{{Id: "2", Title: "Two"}}},
+	}}
+	if untitled.Validate() == nil {
+		t.Errorf("WhatsApp list with an untitled second section should be invalid")
+	}
+
+	longButton := WhatsAppList{Body: "Pick one", Button: "This button text is far too long", Sections: []WhatsAppListSection{
+		{Rows: []WhatsAppListRow{{Id: "1", Title: "One"}}},
+	}}
+	if longButton.Validate() == nil {
+		t.Errorf("WhatsApp list with long button text should be invalid")
+	}
+}
+
+func TestWhatsAppReplyButtons(t *testing.T) {
+	buttons := WhatsAppReplyButtons{
+		Body:   "Keep your appointment?",
+		Footer: "Reply by 5pm",
+		Buttons: []WhatsAppReplyButton{
+			{Id: "yes", Title: "Yes"},
+			{Id: "no", Title: "No"},
+		},
+	}
+
+	if err := buttons.Validate(); err != nil {
+		t.Errorf("WhatsApp reply buttons should be valid: %s", err)
+	}
+
+	j, _ := json.Marshal(buttons)
+	expected := `{"type":"button","body":{"text":"Keep your appointment?"},"footer":{"text":"Reply by 5pm"},"action":{"buttons":[{"type":"reply","reply":{"id":"yes","title":"Yes"}},{"type":"reply","reply":{"id":"no","title":"No"}}]}}`
+	if string(j) != expected {
+		t.Errorf("Unexpected JSON format for: WhatsApp reply buttons: %s", j)
+	}
+
+	buttons.Buttons = append(buttons.Buttons, WhatsAppReplyButton{Id: "maybe", Title: "Maybe"}, WhatsAppReplyButton{Id: "later", Title: "Later"})
+	if buttons.Validate() == nil {
+		t.Errorf("WhatsApp reply buttons with four buttons should be invalid")
+	}
+}
+
+func TestWhatsAppSendInvalid(t *testing.T) {
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewMessagesClient(auth)
+	_, _, err := client.Send(MessagesWhatsAppReplyButtons{To: "447700900000", From: "447700900001"})
+
+	if err == nil {
+		t.Errorf("Sending invalid WhatsApp reply buttons should fail before the request")
+	}
+}
+
+func TestMessagesSendValidatesFailover(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/messages",
+		func(req *http.Request) (*http.Response, error) {
+			calls++
+			return httpmock.NewStringResponse(202, `{"message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}`), nil
+		},
+	)
+
+	client := NewMessagesClient(&JWTAuth{JWT: "a.b.c"})
+	message := MessagesWhatsAppText{
+		To:   "447700900000",
+		From: "447700900001",
+		Text: "Which store?",
+		Failover: []MessagesMessage{MessagesWhatsAppList{
+			To:   "447700900000",
+			From: "447700900001",
+			List: WhatsAppList{Body: "Which store?", Button: "Choose"},
+		}},
+	}
+	_, _, err := client.Send(message)
+	if err == nil || !strings.HasPrefix(err.Error(), "Failover message 0:") {
+		t.Errorf("Invalid failover message should fail validation, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Invalid failover message should not be sent")
+	}
+}
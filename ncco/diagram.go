@@ -0,0 +1,254 @@
+package ncco
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diagramLabelSize is the longest text shown in a diagram label
+const diagramLabelSize = 40
+
+// Diagram draws one or more NCCOs as a flow chart in Mermaid or DOT
+// (Graphviz) format. Name each NCCO with the URL that serves it and
+// input, notify and synchronous connect actions whose eventUrl is one of
+// those URLs are linked to that NCCO automatically
+type Diagram struct {
+	nccos []diagramNcco
+	links []diagramLink
+}
+
+type diagramNcco struct {
+	name    string
+	actions []interface{}
+}
+
+type diagramLink struct {
+	from  string
+	to    string
+	label string
+}
+
+type diagramNode struct {
+	id     string
+	label  string
+	branch bool
+}
+
+type diagramEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// AddNcco adds an NCCO to the diagram
+func (d *Diagram) AddNcco(name string, n Ncco) {
+	d.nccos = append(d.nccos, diagramNcco{name: name, actions: n.GetActions()})
+}
+
+// Link draws an edge from the last action of the NCCO named from to the
+// first action of the NCCO named to, for flows that aren't linked by URL
+func (d *Diagram) Link(from string, to string, label string) {
+	d.links = append(d.links, diagramLink{from: from, to: to, label: label})
+}
+
+// Mermaid returns the diagram as a Mermaid flowchart
+func (d *Diagram) Mermaid() string {
+	groups, edges := d.graph()
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	for i, group := range groups {
+		fmt.Fprintf(&b, "  subgraph s%d[\"%s\"]\n", i, mermaidEscape(d.nccos[i].name))
+		for _, node := range group {
+			if node.branch {
+				fmt.Fprintf(&b, "    %s{\"%s\"}\n", node.id, mermaidEscape(node.label))
+			} else {
+				fmt.Fprintf(&b, "    %s[\"%s\"]\n", node.id, mermaidEscape(node.label))
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	for _, edge := range edges {
+		if edge.label == "" {
+			fmt.Fprintf(&b, "  %s --> %s\n", edge.from, edge.to)
+		} else {
+			fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", edge.from, mermaidEscape(edge.label), edge.to)
+		}
+	}
+	return b.String()
+}
+
+// Dot returns the diagram as a Graphviz digraph
+func (d *Diagram) Dot() string {
+	groups, edges := d.graph()
+	var b strings.Builder
+	b.WriteString("digraph ncco {\n  node [shape=box];\n")
+	for i, group := range groups {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=\"%s\";\n", i, dotEscape(d.nccos[i].name))
+		for _, node := range group {
+			shape := ""
+			if node.branch {
+				shape = ", shape=diamond"
+			}
+			fmt.Fprintf(&b, "    %s [label=\"%s\"%s];\n", node.id, dotEscape(node.label), shape)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, edge := range edges {
+		if edge.label == "" {
+			fmt.Fprintf(&b, "  %s -> %s;\n", edge.from, edge.to)
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s [label=\"%s\"];\n", edge.from, edge.to, dotEscape(edge.label))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graph works out the nodes for each NCCO and the edges between them
+func (d *Diagram) graph() ([][]diagramNode, []diagramEdge) {
+	first := map[string]string{}
+	last := map[string]string{}
+	groups := make([][]diagramNode, len(d.nccos))
+	for i, n := range d.nccos {
+		for j, action := range n.actions {
+			label, branch := actionLabel(action)
+			groups[i] = append(groups[i], diagramNode{id: fmt.Sprintf("n%d_%d", i, j), label: label, branch: branch})
+		}
+		if len(groups[i]) == 0 {
+			groups[i] = append(groups[i], diagramNode{id: fmt.Sprintf("n%d_end", i), label: "hang up"})
+		}
+		if _, seen := first[n.name]; !seen {
+			first[n.name] = groups[i][0].id
+		}
+		last[n.name] = groups[i][len(groups[i])-1].id
+	}
+
+	var edges []diagramEdge
+	for i, n := range d.nccos {
+		for j, action := range n.actions {
+			from := groups[i][j].id
+			eventUrl, label := actionEventUrl(action)
+			if target, ok := first[eventUrl]; ok && eventUrl != "" {
+				edges = append(edges, diagramEdge{from: from, to: target, label: label})
+			}
+			// the NCCO from the eventUrl replaces the rest of this one
+			if replacesNcco(action) {
+				break
+			}
+			if j+1 < len(n.actions) {
+				edges = append(edges, diagramEdge{from: from, to: groups[i][j+1].id})
+			}
+		}
+	}
+
+	for _, link := range d.links {
+		from, fromOk := last[link.from]
+		to, toOk := first[link.to]
+		if fromOk && toOk {
+			edges = append(edges, diagramEdge{from: from, to: to, label: link.label})
+		}
+	}
+	return groups, edges
+}
+
+// actionEventUrl returns the eventUrl that an action's next NCCO or
+// payload is sent to, with the label for the edge
+func actionEventUrl(action interface{}) (string, string) {
+	switch a := action.(type) {
+	case InputAction:
+		if len(a.EventUrl) > 0 {
+			return a.EventUrl[0], "input"
+		}
+	case NotifyAction:
+		if len(a.EventUrl) > 0 {
+			return a.EventUrl[0], "notify"
+		}
+	case ConnectAction:
+		if a.EventType == "synchronous" && len(a.EventUrl) > 0 {
+			return a.EventUrl[0], "connect event"
+		}
+	}
+	return "", ""
+}
+
+// actionLabel describes the action and its key parameters. Branch is true
+// for actions where the call flow can go more than one way
+func actionLabel(action interface{}) (string, bool) {
+	switch a := action.(type) {
+	case TalkAction:
+		return "talk: " + shorten(a.Text), false
+	case StreamAction:
+		return "stream: " + shorten(strings.Join(a.StreamUrl, " ")), false
+	case NotifyAction:
+		return "notify: " + shorten(strings.Join(a.EventUrl, " ")), false
+	case RecordAction:
+		format := a.Format
+		if format == "" {
+			format = "mp3"
+		}
+		return "record: " + format, false
+	case ConversationAction:
+		return "conversation: " + shorten(a.Name), false
+	case InputAction:
+		var kinds []string
+		if a.Dtmf != nil || (len(a.Type) == 0 && a.Speech == nil) {
+			kind := "dtmf"
+			if a.Dtmf != nil && a.Dtmf.MaxDigits > 0 {
+				kind = fmt.Sprintf("dtmf %d digits", a.Dtmf.MaxDigits)
+			}
+			kinds = append(kinds, kind)
+		}
+		if a.Speech != nil {
+			kinds = append(kinds, "speech")
+		}
+		return "input: " + strings.Join(kinds, ", "), true
+	case ConnectAction:
+		var targets []string
+		for _, endpoint := range a.Endpoint {
+			targets = append(targets, endpointLabel(endpoint))
+		}
+		return "connect: " + shorten(strings.Join(targets, ", ")), a.EventType == "synchronous"
+	}
+	return "unknown action", false
+}
+
+func endpointLabel(endpoint Endpoint) string {
+	switch e := endpoint.(type) {
+	case PhoneEndpoint:
+		return "phone " + e.Number
+	case WebSocketEndpoint:
+		return "websocket " + e.Uri
+	case SIPEndpoint:
+		return "sip " + e.Uri
+	case AppEndpoint:
+		return "app " + e.User
+	case VBCEndpoint:
+		return "vbc " + e.Extension
+	}
+	return "endpoint"
+}
+
+func shorten(text string) string {
+	runes := []rune(text)
+	if len(runes) <= diagramLabelSize {
+		return text
+	}
+	return string(runes[:diagramLabelSize-3]) + "..."
+}
+
+// lineBreaks turns \r\n and \r into \n, so each format only has one kind
+// of line break to replace
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func mermaidEscape(text string) string {
+	text = lineBreaks.Replace(text)
+	text = strings.Replace(text, "\"", "#quot;", -1)
+	return strings.Replace(text, "\n", "<br>", -1)
+}
+
+func dotEscape(text string) string {
+	text = lineBreaks.Replace(text)
+	text = strings.Replace(text, "\\", "\\\\", -1)
+	text = strings.Replace(text, "\"", "\\\"", -1)
+	return strings.Replace(text, "\n", "\\n", -1)
+}
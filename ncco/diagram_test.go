@@ -0,0 +1,85 @@
+package ncco
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagramSingleNcco(t *testing.T) {
+	ncco := Ncco{}
+	ncco.AddAction(TalkAction{Text: "Say \"sales\" or press 1"})
+	ncco.AddAction(InputAction{EventUrl: []string{"https://example.com/input"}, Dtmf: &DtmfInput{MaxDigits: 1}, Speech: &SpeechInput{}})
+	ncco.AddAction(TalkAction{Text: "Never played"})
+
+	d := Diagram{}
+	d.AddNcco("answer", ncco)
+
+	expected := `flowchart TD
+  subgraph s0["answer"]
+    n0_0["talk: Say #quot;sales#quot; or press 1"]
+    n0_1{"input: dtmf 1 digits, speech"}
+    n0_2["talk: Never played"]
+  end
+  n0_0 --> n0_1
+`
+	if d.Mermaid() != expected {
+		t.Errorf("Unexpected Mermaid format:\n%s", d.Mermaid())
+	}
+}
+
+func TestDiagramLinkedByUrl(t *testing.T) {
+	answer := Ncco{}
+	answer.AddAction(StreamAction{StreamUrl: []string{"https://example.com/welcome-to-our-very-long-named-company.mp3"}})
+	answer.AddAction(InputAction{EventUrl: []string{"https://example.com/menu"}})
+
+	menu := Ncco{}
+	menu.AddAction(ConnectAction{Endpoint: []Endpoint{PhoneEndpoint{Number: "447700900000"}, AppEndpoint{User: "jamie"}}})
+	menu.AddAction(RecordAction{Format: "wav"})
+
+	d := Diagram{}
+	d.AddNcco("https://example.com/answer", answer)
+	d.AddNcco("https://example.com/menu", menu)
+	d.Link("https://example.com/menu", "https://example.com/answer", "again")
+
+	expected := `digraph ncco {
+  node [shape=box];
+  subgraph cluster_0 {
+    label="https://example.com/answer";
+    n0_0 [label="stream: https://example.com/welcome-to-our-ve..."];
+    n0_1 [label="input: dtmf", shape=diamond];
+  }
+  subgraph cluster_1 {
+    label="https://example.com/menu";
+    n1_0 [label="connect: phone 447700900000, app jamie"];
+    n1_1 [label="record: wav"];
+  }
+  n0_0 -> n0_1;
+  n0_1 -> n1_0 [label="input"];
+  n1_0 -> n1_1;
+  n1_1 -> n0_0 [label="again"];
+}
+`
+	if d.Dot() != expected {
+		t.Errorf("Unexpected DOT format:\n%s", d.Dot())
+	}
+}
+
+func TestDiagramMultilineText(t *testing.T) {
+	ncco := Ncco{}
+	ncco.AddAction(TalkAction{Text: "Welcome\nto \"Acme\"\r\nGoodbye"})
+
+	d := Diagram{}
+	d.AddNcco("answer", ncco)
+
+	mermaid := `flowchart TD
+  subgraph s0["answer"]
+    n0_0["talk: Welcome<br>to #quot;Acme#quot;<br>Goodbye"]
+  end
+`
+	if d.Mermaid() != mermaid {
+		t.Errorf("Unexpected Mermaid format:\n%s", d.Mermaid())
+	}
+	if dot := d.Dot(); !strings.Contains(dot, `label="talk: Welcome\nto \"Acme\"\nGoodbye"`) {
+		t.Errorf("Unexpected DOT format:\n%s", dot)
+	}
+}
@@ -2,6 +2,8 @@ package ncco
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -32,9 +34,148 @@ func (n Ncco) MarshalJSON() ([]byte, error) {
 	return json.Marshal(n.GetActions())
 }
 
+// UnmarshalJSON reads an NCCO array into the typed actions, so
+// GetActions() returns TalkAction, ConnectAction and so on. The Loop and
+// StartOnEnter strings are set from the JSON values
+func (n *Ncco) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	actions := make([]interface{}, 0, len(raw))
+	for i, item := range raw {
+		action, err := unmarshalAction(item)
+		if err != nil {
+			return fmt.Errorf("NCCO action %d: %s", i, err)
+		}
+		actions = append(actions, action.prepare())
+	}
+	n.actions = actions
+	return nil
+}
+
+// unmarshalAction picks the action type from the "action" field
+func unmarshalAction(data []byte) (Action, error) {
+	var fields struct {
+		Action       string `json:"action"`
+		Loop         *int   `json:"loop"`
+		StartOnEnter *bool  `json:"startOnEnter"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// the loop and startOnEnter values are only used if they were given,
+	// otherwise prepare() sets the defaults
+	loop := ""
+	if fields.Loop != nil {
+		loop = strconv.Itoa(*fields.Loop)
+	}
+
+	switch fields.Action {
+	case "talk":
+		var a TalkAction
+		err := json.Unmarshal(data, &a)
+		a.Loop = loop
+		return a, err
+	case "stream":
+		var a StreamAction
+		err := json.Unmarshal(data, &a)
+		a.Loop = loop
+		return a, err
+	case "conversation":
+		var a ConversationAction
+		err := json.Unmarshal(data, &a)
+		if fields.StartOnEnter != nil {
+			a.StartOnEnter = strconv.FormatBool(*fields.StartOnEnter)
+		}
+		return a, err
+	case "notify":
+		var a NotifyAction
+		err := json.Unmarshal(data, &a)
+		return a, err
+	case "record":
+		var a RecordAction
+		err := json.Unmarshal(data, &a)
+		return a, err
+	case "input":
+		var a InputAction
+		err := json.Unmarshal(data, &a)
+		return a, err
+	case "connect":
+		return unmarshalConnect(data)
+	}
+	return nil, fmt.Errorf("Unknown action %q", fields.Action)
+}
+
+func unmarshalConnect(data []byte) (Action, error) {
+	// fields has the endpoints as raw JSON, shadowing the
+	// Endpoint interface slice which can't be decoded directly
+	type connectAlias ConnectAction
+	var fields struct {
+		connectAlias
+		Endpoint []json.RawMessage `json:"endpoint"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	a := ConnectAction(fields.connectAlias)
+	a.Endpoint = nil
+	for i, item := range fields.Endpoint {
+		endpoint, err := unmarshalEndpoint(item)
+		if err != nil {
+			return nil, fmt.Errorf("Endpoint %d: %s", i, err)
+		}
+		a.Endpoint = append(a.Endpoint, endpoint)
+	}
+	return a, nil
+}
+
+// unmarshalEndpoint picks the endpoint type from the "type" field
+func unmarshalEndpoint(data []byte) (Endpoint, error) {
+	var fields struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	var endpoint Endpoint
+	var err error
+	switch fields.Type {
+	case "phone":
+		var e PhoneEndpoint
+		err = json.Unmarshal(data, &e)
+		endpoint = e
+	case "websocket":
+		var e WebSocketEndpoint
+		err = json.Unmarshal(data, &e)
+		endpoint = e
+	case "sip":
+		var e SIPEndpoint
+		err = json.Unmarshal(data, &e)
+		endpoint = e
+	case "app":
+		var e AppEndpoint
+		err = json.Unmarshal(data, &e)
+		endpoint = e
+	case "vbc":
+		var e VBCEndpoint
+		err = json.Unmarshal(data, &e)
+		endpoint = e
+	default:
+		return nil, fmt.Errorf("Unknown endpoint type %q", fields.Type)
+	}
+	return endpoint, err
+}
+
 // TalkAction is a text-to-speech feature. Beware that the "Loop"
 // field is a string here, and the CalculatedLoopValue is used to
-// assemble the correct value when sending
+// assemble the correct value when sending. Choose the voice with Language
+// and Style, VoiceName is deprecated. Text
+// can be SSML built with tts.SSML
 type TalkAction struct {
 	Action              string `json:"action"`
 	Text                string `json:"text"`
@@ -42,6 +183,9 @@ type TalkAction struct {
 	BargeIn             bool   `json:"bargeIn"`
 	Level               int    `json:"level,omitempty"`
 	VoiceName           string `json:"voiceName,omitempty"`
+	Language            string `json:"language,omitempty"`
+	Style               int    `json:"style,omitempty"`
+	Premium             bool   `json:"premium,omitempty"`
 	CalculatedLoopValue int    `json:"loop"`
 }
 
@@ -76,16 +220,26 @@ func (a NotifyAction) prepare() Action {
 
 // RecordAction to start a recording at this point in the call
 type RecordAction struct {
-	Action       string   `json:"action"`
-	Format       string   `json:"format,omitempty"`
-	Split        string   `json:"split,omitempty"`
-	Channels     int      `json:"channels,omitempty"`
-	EndOnSilence int      `json:"endOnSilence,omitempty"`
-	EndOnKey     string   `json:"endOnKey,omitempty"`
-	TimeOut      int      `json:"timeOut,omitempty"`
-	BeepStart    bool     `json:"beepStart,omitempty"`
-	EventUrl     []string `json:"eventUrl,omitempty"`
-	EventMethod  string   `json:"eventMethod,omitempty"`
+	Action        string               `json:"action"`
+	Format        string               `json:"format,omitempty"`
+	Split         string               `json:"split,omitempty"`
+	Channels      int                  `json:"channels,omitempty"`
+	EndOnSilence  int                  `json:"endOnSilence,omitempty"`
+	EndOnKey      string               `json:"endOnKey,omitempty"`
+	TimeOut       int                  `json:"timeOut,omitempty"`
+	BeepStart     bool                 `json:"beepStart,omitempty"`
+	EventUrl      []string             `json:"eventUrl,omitempty"`
+	EventMethod   string               `json:"eventMethod,omitempty"`
+	Transcription *RecordTranscription `json:"transcription,omitempty"`
+}
+
+// RecordTranscription turns on transcription for a recording. When it
+// is ready, a transcription event is sent to the EventUrl
+type RecordTranscription struct {
+	EventUrl          []string `json:"eventUrl,omitempty"`
+	EventMethod       string   `json:"eventMethod,omitempty"`
+	Language          string   `json:"language,omitempty"`
+	SentimentAnalysis bool     `json:"sentimentAnalysis,omitempty"`
 }
 
 // prepare for the RecordAction
@@ -146,17 +300,27 @@ func (a StreamAction) prepare() Action {
 	return a
 }
 
-// InputAction uses pointers for the optional dtmf input
+// InputAction uses pointers for the optional dtmf and speech inputs.
+// Type lists the kinds of input to accept, "dtmf" and/or "speech"; if
+// it's empty and Speech is set then it is filled in from the inputs
 type InputAction struct {
-	Action      string     `json:"action"`
-	Dtmf        *DtmfInput `json:"dtmf,omitempty"`
-	EventUrl    []string   `json:"eventUrl,omitempty"`
-	EventMethod string     `json:"eventMethod,omitempty"`
+	Action      string       `json:"action"`
+	Type        []string     `json:"type,omitempty"`
+	Dtmf        *DtmfInput   `json:"dtmf,omitempty"`
+	Speech      *SpeechInput `json:"speech,omitempty"`
+	EventUrl    []string     `json:"eventUrl,omitempty"`
+	EventMethod string       `json:"eventMethod,omitempty"`
 }
 
 // prepare for the InputAction
 func (a InputAction) prepare() Action {
 	a.Action = "input"
+	if len(a.Type) == 0 && a.Speech != nil {
+		if a.Dtmf != nil {
+			a.Type = append(a.Type, "dtmf")
+		}
+		a.Type = append(a.Type, "speech")
+	}
 	return a
 }
 
@@ -167,6 +331,114 @@ type DtmfInput struct {
 	SubmitOnHash bool `json:"submitOnHash,omitempty"`
 }
 
+// SpeechInput recognises what the caller says. Uuid restricts recognition
+// to one leg of the call, Context gives hints of the words to expect and
+// SaveAudio adds a recording of the speech to the input result.
+// Sensitivity is a pointer so that 0 can be sent, nil leaves the default
+type SpeechInput struct {
+	Uuid         []string `json:"uuid,omitempty"`
+	EndOnSilence float64  `json:"endOnSilence,omitempty"`
+	Language     string   `json:"language,omitempty"`
+	Context      []string `json:"context,omitempty"`
+	StartTimeout int      `json:"startTimeout,omitempty"`
+	MaxDuration  int      `json:"maxDuration,omitempty"`
+	SaveAudio    bool     `json:"saveAudio,omitempty"`
+	Sensitivity  *int     `json:"sensitivity,omitempty"`
+}
+
+//--- Input Results ---
+
+// InputResult is what an input action collected, sent in the webhook to
+// its eventUrl
+type InputResult struct {
+	Dtmf   *DtmfResult   `json:"dtmf,omitempty"`
+	Speech *SpeechResult `json:"speech,omitempty"`
+}
+
+// DtmfResult holds the digits the caller pressed
+type DtmfResult struct {
+	Digits   string `json:"digits"`
+	TimedOut bool   `json:"timed_out"`
+}
+
+// SpeechResult holds the speech recognition results, most likely first
+type SpeechResult struct {
+	TimeoutReason string             `json:"timeout_reason,omitempty"`
+	Results       []SpeechTranscript `json:"results,omitempty"`
+	RecordingUrl  string             `json:"recording_url,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// SpeechTranscript is one possible transcription of what the caller said
+type SpeechTranscript struct {
+	Confidence float64 `json:"confidence"`
+	Text       string  `json:"text"`
+}
+
+// UnmarshalJSON accepts the confidence as a number or a string, Vonage
+// sends a string
+func (r *SpeechTranscript) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Confidence json.Number `json:"confidence"`
+		Text       string      `json:"text"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.Text = raw.Text
+	if raw.Confidence != "" {
+		confidence, err := raw.Confidence.Float64()
+		if err != nil {
+			return err
+		}
+		r.Confidence = confidence
+	}
+	return nil
+}
+
+// ParseInputResult reads the body of an input webhook
+func ParseInputResult(data []byte) (InputResult, error) {
+	var result InputResult
+	err := json.Unmarshal(data, &result)
+	return result, err
+}
+
+// Digits returns the DTMF digits, or "" if there weren't any
+func (r InputResult) Digits() string {
+	if r.Dtmf == nil {
+		return ""
+	}
+	return r.Dtmf.Digits
+}
+
+// BestTranscript returns the speech result with the highest confidence
+func (r InputResult) BestTranscript() (SpeechTranscript, bool) {
+	if r.Speech == nil || len(r.Speech.Results) == 0 {
+		return SpeechTranscript{}, false
+	}
+	best := r.Speech.Results[0]
+	for _, result := range r.Speech.Results[1:] {
+		if result.Confidence > best.Confidence {
+			best = result
+		}
+	}
+	return best, true
+}
+
+// TimedOut is true when neither digits nor speech were collected before
+// the input timed out
+func (r InputResult) TimedOut() bool {
+	if r.Digits() != "" {
+		return false
+	}
+	if _, ok := r.BestTranscript(); ok {
+		return false
+	}
+	dtmfTimedOut := r.Dtmf != nil && r.Dtmf.TimedOut
+	speechTimedOut := r.Speech != nil && r.Speech.TimeoutReason != ""
+	return dtmfTimedOut || speechTimedOut
+}
+
 // ConnectAction takes an Endpoint (of which there are many) and joins
 // it into the current call
 type ConnectAction struct {
@@ -185,16 +457,30 @@ type ConnectAction struct {
 // prepare for the ConnectAction
 func (a ConnectAction) prepare() Action {
 	a.Action = "connect"
-	// organise the endpoint
-	a.Endpoint[0] = a.Endpoint[0].prepareEndpoint()
+	// organise the endpoints, copying so the caller's slice is untouched
+	endpoints := make([]Endpoint, len(a.Endpoint))
+	for i, endpoint := range a.Endpoint {
+		endpoints[i] = endpoint.prepareEndpoint()
+	}
+	a.Endpoint = endpoints
 	return a
 }
 
+// Validate checks that there is at least one endpoint and that every
+// endpoint has the fields its type needs
+func (a ConnectAction) Validate() error {
+	if problems := a.validate(); len(problems) > 0 {
+		return errors.New(problems[0])
+	}
+	return nil
+}
+
 //--- Connect Endpoints ---
 
 // Endpoint is a mostly dummy interface to let us typehint on it
 type Endpoint interface {
 	prepareEndpoint() Endpoint
+	validateEndpoint() error
 }
 
 type PhoneEndpoint struct {
@@ -208,3 +494,94 @@ func (e PhoneEndpoint) prepareEndpoint() Endpoint {
 	e.Type = "phone"
 	return e
 }
+
+func (e PhoneEndpoint) validateEndpoint() error {
+	if e.Number == "" {
+		return errors.New("Phone endpoints need a Number")
+	}
+	return nil
+}
+
+// WebSocketEndpoint streams the call audio to your server. ContentType
+// defaults to audio/l16;rate=16000, the other option is 8000
+type WebSocketEndpoint struct {
+	Type        string            `json:"type"`
+	Uri         string            `json:"uri"`
+	ContentType string            `json:"content-type"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+func (e WebSocketEndpoint) prepareEndpoint() Endpoint {
+	e.Type = "websocket"
+	if e.ContentType == "" {
+		e.ContentType = "audio/l16;rate=16000"
+	}
+	return e
+}
+
+func (e WebSocketEndpoint) validateEndpoint() error {
+	if !strings.HasPrefix(e.Uri, "ws://") && !strings.HasPrefix(e.Uri, "wss://") {
+		return errors.New("Websocket endpoints need a Uri starting ws:// or wss://")
+	}
+	if e.ContentType != "" && e.ContentType != "audio/l16;rate=8000" && e.ContentType != "audio/l16;rate=16000" {
+		return errors.New("Websocket ContentType must be audio/l16;rate=8000 or audio/l16;rate=16000")
+	}
+	return nil
+}
+
+// SIPEndpoint connects to a SIP URI. Headers are sent as X- headers and
+// StandardHeaders holds headers such as User-to-User
+type SIPEndpoint struct {
+	Type            string            `json:"type"`
+	Uri             string            `json:"uri"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	StandardHeaders map[string]string `json:"standardHeaders,omitempty"`
+}
+
+func (e SIPEndpoint) prepareEndpoint() Endpoint {
+	e.Type = "sip"
+	return e
+}
+
+func (e SIPEndpoint) validateEndpoint() error {
+	if !strings.HasPrefix(e.Uri, "sip:") && !strings.HasPrefix(e.Uri, "sips:") {
+		return errors.New("SIP endpoints need a Uri starting sip: or sips:")
+	}
+	return nil
+}
+
+// AppEndpoint connects to a user of a Client SDK application
+type AppEndpoint struct {
+	Type string `json:"type"`
+	User string `json:"user"`
+}
+
+func (e AppEndpoint) prepareEndpoint() Endpoint {
+	e.Type = "app"
+	return e
+}
+
+func (e AppEndpoint) validateEndpoint() error {
+	if e.User == "" {
+		return errors.New("App endpoints need a User")
+	}
+	return nil
+}
+
+// VBCEndpoint connects to a Vonage Business Communications extension
+type VBCEndpoint struct {
+	Type      string `json:"type"`
+	Extension string `json:"extension"`
+}
+
+func (e VBCEndpoint) prepareEndpoint() Endpoint {
+	e.Type = "vbc"
+	return e
+}
+
+func (e VBCEndpoint) validateEndpoint() error {
+	if e.Extension == "" {
+		return errors.New("VBC endpoints need an Extension")
+	}
+	return nil
+}
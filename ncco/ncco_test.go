@@ -129,6 +129,48 @@ func TestNccoConnectAllPhone(t *testing.T) {
 	}
 }
 
+func TestNccoConnectMultipleEndpoints(t *testing.T) {
+	ncco := Ncco{}
+	endpoint := []Endpoint{
+		WebSocketEndpoint{Uri: "wss://example.com/socket", Headers: map[string]string{"caller": "447770007777"}},
+		SIPEndpoint{Uri: "sip:rebekka@sip.example.com", Headers: map[string]string{"location": "london"}, StandardHeaders: map[string]string{"User-to-User": "342342ef34;encoding=hex"}},
+		AppEndpoint{User: "jamie"},
+		VBCEndpoint{Extension: "1234"},
+	}
+	connect := ConnectAction{Endpoint: endpoint}
+	if err := connect.Validate(); err != nil {
+		t.Errorf("Connect action should be valid: %s", err)
+	}
+	ncco.AddAction(connect)
+
+	j, _ := json.Marshal(ncco)
+	if string(j) != "[{\"action\":\"connect\",\"endpoint\":[{\"type\":\"websocket\",\"uri\":\"wss://example.com/socket\",\"content-type\":\"audio/l16;rate=16000\",\"headers\":{\"caller\":\"447770007777\"}},{\"type\":\"sip\",\"uri\":\"sip:rebekka@sip.example.com\",\"headers\":{\"location\":\"london\"},\"standardHeaders\":{\"User-to-User\":\"342342ef34;encoding=hex\"}},{\"type\":\"app\",\"user\":\"jamie\"},{\"type\":\"vbc\",\"extension\":\"1234\"}]}]" {
+		t.Errorf("Unexpected JSON format for: Connect multiple endpoints: %s", j)
+	}
+
+	// the caller's endpoints are not changed by preparing them
+	if endpoint[2].(AppEndpoint).Type != "" {
+		t.Errorf("Endpoints should be copied when preparing")
+	}
+}
+
+func TestNccoConnectValidate(t *testing.T) {
+	invalid := []ConnectAction{
+		{},
+		{Endpoint: []Endpoint{PhoneEndpoint{}}},
+		{Endpoint: []Endpoint{PhoneEndpoint{Number: "447770007777"}, WebSocketEndpoint{Uri: "https://example.com/socket"}}},
+		{Endpoint: []Endpoint{WebSocketEndpoint{Uri: "wss://example.com/socket", ContentType: "audio/l16;rate=44100"}}},
+		{Endpoint: []Endpoint{SIPEndpoint{Uri: "rebekka@sip.example.com"}}},
+		{Endpoint: []Endpoint{AppEndpoint{}}},
+		{Endpoint: []Endpoint{VBCEndpoint{}}},
+	}
+	for i, connect := range invalid {
+		if connect.Validate() == nil {
+			t.Errorf("Connect action %d should be invalid", i)
+		}
+	}
+}
+
 func TestNccoStreamSimple(t *testing.T) {
 	ncco := Ncco{}
 	stream := StreamAction{StreamUrl: []string{"https://example.com/music.mp3"}}
@@ -177,3 +219,155 @@ func TestNccoInputDtmfOptions(t *testing.T) {
 		t.Errorf("Unexpected JSON format for: Input DTMF options")
 	}
 }
+
+func TestNccoInputSpeech(t *testing.T) {
+	ncco := Ncco{}
+	input := InputAction{
+		EventUrl: []string{"https://example.com/event"},
+		Speech:   &SpeechInput{Language: "en-GB", Context: []string{"sales", "support"}, EndOnSilence: 1.5, MaxDuration: 10, SaveAudio: true},
+	}
+	ncco.AddAction(input)
+
+	j, _ := json.Marshal(ncco)
+	if string(j) != "[{\"action\":\"input\",\"type\":[\"speech\"],\"speech\":{\"endOnSilence\":1.5,\"language\":\"en-GB\",\"context\":[\"sales\",\"support\"],\"maxDuration\":10,\"saveAudio\":true},\"eventUrl\":[\"https://example.com/event\"]}]" {
+		t.Errorf("Unexpected JSON format for: Input speech: %s", j)
+	}
+}
+
+func TestNccoInputDtmfAndSpeech(t *testing.T) {
+	ncco := Ncco{}
+	sensitivity := 50
+	input := InputAction{Dtmf: &DtmfInput{MaxDigits: 1}, Speech: &SpeechInput{Uuid: []string{"aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}, Sensitivity: &sensitivity}}
+	ncco.AddAction(input)
+
+	j, _ := json.Marshal(ncco)
+	if string(j) != "[{\"action\":\"input\",\"type\":[\"dtmf\",\"speech\"],\"dtmf\":{\"maxDigits\":1},\"speech\":{\"uuid\":[\"aaaaaaaa-bbbb-cccc-dddd-0123456789ab\"],\"sensitivity\":50}}]" {
+		t.Errorf("Unexpected JSON format for: Input DTMF and speech: %s", j)
+	}
+}
+
+func TestParseInputResult(t *testing.T) {
+	result, err := ParseInputResult([]byte(`{"speech":{"results":[{"confidence":"0.61","text":"support"},{"confidence":"0.93","text":"sales"}]},"dtmf":{"digits":null,"timed_out":false},"uuid":"abc"}`))
+	if err != nil {
+		t.Fatalf("Input result should parse: %s", err)
+	}
+
+	best, ok := result.BestTranscript()
+	if !ok || best.Text != "sales" || best.Confidence != 0.93 {
+		t.Errorf("Unexpected best transcript: %+v", best)
+	}
+	if result.Digits() != "" || result.TimedOut() {
+		t.Errorf("Unexpected DTMF result: %+v", result.Dtmf)
+	}
+
+	result, _ = ParseInputResult([]byte(`{"dtmf":{"digits":"","timed_out":true}}`))
+	if !result.TimedOut() {
+		t.Errorf("Input result should have timed out")
+	}
+
+	result, _ = ParseInputResult([]byte(`{"speech":{"timeout_reason":"start_timeout"}}`))
+	if !result.TimedOut() {
+		t.Errorf("Speech input result should have timed out")
+	}
+}
+
+func TestNccoUnmarshalRoundTrip(t *testing.T) {
+	original := Ncco{}
+	original.AddAction(TalkAction{Text: "Hello", Loop: "3", BargeIn: true, VoiceName: "Amy"})
+	original.AddAction(StreamAction{StreamUrl: []string{"https://example.com/music.mp3"}, Loop: "0"})
+	original.AddAction(RecordAction{Format: "wav", Channels: 2, EventUrl: []string{"https://example.com/record"}})
+	original.AddAction(NotifyAction{Payload: map[string]string{"step": "1"}, EventUrl: []string{"https://example.com/notify"}})
+	original.AddAction(InputAction{Dtmf: &DtmfInput{MaxDigits: 2}, Speech: &SpeechInput{Language: "en-GB"}, EventUrl: []string{"https://example.com/input"}})
+	original.AddAction(ConnectAction{From: "447770008888", Endpoint: []Endpoint{
+		PhoneEndpoint{Number: "447770007777"},
+		WebSocketEndpoint{Uri: "wss://example.com/socket", ContentType: "audio/l16;rate=8000"},
+		SIPEndpoint{Uri: "sip:rebekka@sip.example.com"},
+		AppEndpoint{User: "jamie"},
+		VBCEndpoint{Extension: "1234"},
+	}})
+	original.AddAction(ConversationAction{Name: "standup", StartOnEnter: "false", EndOnExit: true})
+
+	j, _ := json.Marshal(original)
+
+	var parsed Ncco
+	if err := json.Unmarshal(j, &parsed); err != nil {
+		t.Fatalf("NCCO should unmarshal: %s", err)
+	}
+	if len(parsed.GetActions()) != 7 {
+		t.Fatalf("Unexpected number of ncco items: %d", len(parsed.GetActions()))
+	}
+
+	again, _ := json.Marshal(parsed)
+	if string(again) != string(j) {
+		t.Errorf("Unexpected JSON format for: NCCO round trip\n%s\n%s", j, again)
+	}
+
+	talk := parsed.GetActions()[0].(TalkAction)
+	if talk.Loop != "3" || talk.VoiceName != "Amy" {
+		t.Errorf("Unexpected talk action: %+v", talk)
+	}
+	stream := parsed.GetActions()[1].(StreamAction)
+	if stream.Loop != "0" || stream.CalculatedLoopValue != 0 {
+		t.Errorf("Stream should loop forever: %+v", stream)
+	}
+	connect := parsed.GetActions()[5].(ConnectAction)
+	if ws, ok := connect.Endpoint[1].(WebSocketEndpoint); !ok || ws.ContentType != "audio/l16;rate=8000" {
+		t.Errorf("Unexpected websocket endpoint: %+v", connect.Endpoint[1])
+	}
+	conversation := parsed.GetActions()[6].(ConversationAction)
+	if conversation.StartOnEnter != "false" || conversation.CalculatedStartOnEnterValue {
+		t.Errorf("Unexpected conversation action: %+v", conversation)
+	}
+}
+
+func TestNccoUnmarshalDefaults(t *testing.T) {
+	var parsed Ncco
+	err := json.Unmarshal([]byte(`[{"action":"talk","text":"Hi"},{"action":"conversation","name":"room"}]`), &parsed)
+	if err != nil {
+		t.Fatalf("NCCO should unmarshal: %s", err)
+	}
+
+	j, _ := json.Marshal(parsed)
+	if string(j) != "[{\"action\":\"talk\",\"text\":\"Hi\",\"bargeIn\":false,\"loop\":1},{\"action\":\"conversation\",\"name\":\"room\",\"startOnEnter\":true}]" {
+		t.Errorf("Unexpected JSON format for: NCCO defaults: %s", j)
+	}
+}
+
+func TestNccoUnmarshalInvalid(t *testing.T) {
+	invalid := []string{
+		`{"action":"talk"}`,
+		`[{"action":"dance"}]`,
+		`[{"action":"connect","endpoint":[{"type":"pigeon"}]}]`,
+	}
+	for _, body := range invalid {
+		var parsed Ncco
+		if err := json.Unmarshal([]byte(body), &parsed); err == nil {
+			t.Errorf("NCCO should not unmarshal: %s", body)
+		}
+	}
+}
+
+func TestNccoRecordTranscription(t *testing.T) {
+	ncco := Ncco{}
+	record := RecordAction{Transcription: &RecordTranscription{EventUrl: []string{"https://example.com/transcription"}, Language: "en-GB", SentimentAnalysis: true}}
+	ncco.AddAction(record)
+
+	j, _ := json.Marshal(ncco)
+	if string(j) != "[{\"action\":\"record\",\"transcription\":{\"eventUrl\":[\"https://example.com/transcription\"],\"language\":\"en-GB\",\"sentimentAnalysis\":true}}]" {
+		t.Errorf("Unexpected JSON format for: Record transcription: %s", j)
+	}
+}
+
+func TestNccoInputSpeechZeroSensitivity(t *testing.T) {
+	ncco := Ncco{}
+	sensitivity := 0
+	ncco.AddAction(InputAction{EventUrl: []string{"https://example.com/event"}, Speech: &SpeechInput{Sensitivity: &sensitivity}})
+
+	j, _ := json.Marshal(ncco)
+	if string(j) != "[{\"action\":\"input\",\"type\":[\"speech\"],\"speech\":{\"sensitivity\":0},\"eventUrl\":[\"https://example.com/event\"]}]" {
+		t.Errorf("Unexpected JSON format for: Input speech with zero sensitivity: %s", j)
+	}
+	if err := ncco.Validate(); err != nil {
+		t.Errorf("Zero sensitivity should be valid: %s", err)
+	}
+}
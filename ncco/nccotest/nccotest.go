@@ -0,0 +1,356 @@
+// Package nccotest runs call flows locally for tests. A Simulator fetches
+// the NCCO from your answer URL, walks through its actions and sends the
+// same webhooks Vonage would send, with the caller's input scripted by
+// the test
+package nccotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+// Input is what the caller does when an input action runs
+type Input struct {
+	Digits string
+	Speech []string
+}
+
+// Digits scripts the caller pressing keys
+func Digits(digits string) Input {
+	return Input{Digits: digits}
+}
+
+// Speech scripts the caller saying something. Each text is a possible
+// transcription, most likely first
+func Speech(text ...string) Input {
+	return Input{Speech: text}
+}
+
+// Silence scripts the caller doing nothing, so the input times out
+func Silence() Input {
+	return Input{}
+}
+
+// Simulator walks a call flow. All webhooks are served by Handler, using
+// requests for the answer URL, event URL and action eventUrls as given
+type Simulator struct {
+	Handler   http.Handler
+	AnswerUrl string
+	// EventUrl gets the call status events, leave it empty to skip them
+	EventUrl  string
+	To        string
+	From      string
+	Direction string
+	// Inputs are used in order by the input actions, once they run out
+	// the input times out
+	Inputs []Input
+	// MachineDetection is "human" or "machine" to send that event after
+	// the call is answered
+	MachineDetection string
+	// HangupAfter makes the caller hang up once this many actions have
+	// run, 0 means the caller stays until the NCCO ends
+	HangupAfter int
+	// MaxActions stops call flows that never end, it defaults to 100
+	MaxActions int
+	// Now is the clock used for event timestamps, defaults to time.Now
+	Now func() time.Time
+
+	legs int
+}
+
+// Result records what happened during the call
+type Result struct {
+	Uuid             string
+	ConversationUuid string
+	// Actions holds every action that ran, in order
+	Actions []interface{}
+	// Events holds every webhook event sent, in order
+	Events []voicewebhook.Event
+	// Talk holds the text of every talk action that ran
+	Talk []string
+	// DisconnectedBy is "user" if the caller hung up, otherwise "platform"
+	DisconnectedBy string
+}
+
+// EventTypes lists the EventType() of every event sent
+func (r *Result) EventTypes() []string {
+	types := make([]string, len(r.Events))
+	for i, event := range r.Events {
+		types[i] = event.EventType()
+	}
+	return types
+}
+
+// Run makes a request to AnswerUrl and walks the NCCO it returns
+func (s *Simulator) Run() (*Result, error) {
+	result := s.newResult()
+	query := url.Values{}
+	query.Set("to", s.To)
+	query.Set("from", s.From)
+	query.Set("uuid", result.Uuid)
+	query.Set("conversation_uuid", result.ConversationUuid)
+	n, err := s.request("GET", s.AnswerUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return result, err
+	}
+	s.callEvents(result, s.EventUrl, result.Uuid, "started", "ringing", "answered")
+	return result, s.walk(result, n)
+}
+
+// RunNcco walks the NCCO without making an answer request
+func (s *Simulator) RunNcco(n ncco.Ncco) (*Result, error) {
+	result := s.newResult()
+	s.callEvents(result, s.EventUrl, result.Uuid, "started", "ringing", "answered")
+	return result, s.walk(result, n)
+}
+
+func (s *Simulator) newResult() *Result {
+	if s.Direction == "" {
+		s.Direction = "inbound"
+	}
+	if s.MaxActions == 0 {
+		s.MaxActions = 100
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	return &Result{Uuid: s.newUuid(), ConversationUuid: "CON-" + s.newUuid()}
+}
+
+func (s *Simulator) newUuid() string {
+	s.legs++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.legs)
+}
+
+func (s *Simulator) walk(result *Result, n ncco.Ncco) error {
+	if s.MachineDetection != "" {
+		s.send(result, s.EventUrl, voicewebhook.MachineEvent{CallEvent: s.callEvent(result, result.Uuid, s.MachineDetection)})
+	}
+
+	var records []ncco.RecordAction
+	actions := n.GetActions()
+	start := s.Now()
+	inputs := 0
+
+	for len(actions) > 0 {
+		if len(result.Actions) >= s.MaxActions {
+			return fmt.Errorf("Call flow did not finish within %d actions", s.MaxActions)
+		}
+		if s.HangupAfter > 0 && len(result.Actions) >= s.HangupAfter {
+			result.DisconnectedBy = "user"
+			break
+		}
+
+		action := actions[0]
+		actions = actions[1:]
+		result.Actions = append(result.Actions, action)
+
+		var next *ncco.Ncco
+		var err error
+		switch a := action.(type) {
+		case ncco.TalkAction:
+			result.Talk = append(result.Talk, a.Text)
+		case ncco.RecordAction:
+			records = append(records, a)
+		case ncco.NotifyAction:
+			next, err = s.notify(a)
+		case ncco.InputAction:
+			input := Input{}
+			if inputs < len(s.Inputs) {
+				input = s.Inputs[inputs]
+			}
+			inputs++
+			next, err = s.input(result, a, input)
+		case ncco.ConnectAction:
+			next, err = s.connect(result, a)
+		case ncco.ConversationAction:
+			// the caller stays in the conversation until they hang up
+			result.DisconnectedBy = "user"
+			actions = nil
+		}
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			actions = next.GetActions()
+		}
+	}
+
+	if result.DisconnectedBy == "" {
+		result.DisconnectedBy = "platform"
+	}
+	for _, record := range records {
+		s.send(result, firstUrl(record.EventUrl, s.EventUrl), voicewebhook.RecordEvent{
+			StartTime:        start,
+			EndTime:          s.Now(),
+			RecordingUrl:     "https://api.nexmo.com/v1/files/" + s.newUuid(),
+			RecordingUuid:    s.newUuid(),
+			ConversationUuid: result.ConversationUuid,
+			Timestamp:        s.Now(),
+		})
+	}
+	s.completed(result, s.EventUrl, result.Uuid, start, result.DisconnectedBy)
+	return nil
+}
+
+// input sends the scripted input to the action's eventUrl, the NCCO it
+// returns replaces the rest of the call
+func (s *Simulator) input(result *Result, a ncco.InputAction, input Input) (*ncco.Ncco, error) {
+	acceptsDtmf := a.Dtmf != nil || contains(a.Type, "dtmf") || (len(a.Type) == 0 && a.Speech == nil)
+	acceptsSpeech := a.Speech != nil || contains(a.Type, "speech")
+
+	event := voicewebhook.InputEvent{
+		Uuid:             result.Uuid,
+		ConversationUuid: result.ConversationUuid,
+		To:               s.To,
+		From:             s.From,
+		Timestamp:        s.Now(),
+	}
+	if acceptsDtmf {
+		event.Dtmf = &ncco.DtmfResult{Digits: input.Digits, TimedOut: input.Digits == "" && len(input.Speech) == 0}
+	}
+	if acceptsSpeech {
+		event.Speech = &ncco.SpeechResult{}
+		if len(input.Speech) == 0 {
+			event.Speech.TimeoutReason = "start_timeout"
+		}
+		for i, text := range input.Speech {
+			event.Speech.Results = append(event.Speech.Results, ncco.SpeechTranscript{Text: text, Confidence: 0.9 / float64(i+1)})
+		}
+	}
+
+	if len(a.EventUrl) == 0 {
+		return nil, errors.New("Input action has no eventUrl")
+	}
+	result.Events = append(result.Events, event)
+	n, err := s.post(a.EventUrl[0], event)
+	return &n, err
+}
+
+// notify sends the payload, an NCCO in the response replaces the rest of
+// the call
+func (s *Simulator) notify(a ncco.NotifyAction) (*ncco.Ncco, error) {
+	if len(a.EventUrl) == 0 {
+		return nil, errors.New("Notify action has no eventUrl")
+	}
+	n, err := s.post(a.EventUrl[0], a.Payload)
+	if err != nil || len(n.GetActions()) == 0 {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// connect adds a leg for the first endpoint, which answers and then hangs
+// up. A synchronous connect takes the rest of the call from its eventUrl
+func (s *Simulator) connect(result *Result, a ncco.ConnectAction) (*ncco.Ncco, error) {
+	eventUrl := firstUrl(a.EventUrl, s.EventUrl)
+	leg := s.newUuid()
+	start := s.Now()
+	s.callEvents(result, eventUrl, leg, "started", "ringing", "answered")
+	if a.EventType == "synchronous" {
+		n, err := s.post(eventUrl, result.Events[len(result.Events)-1])
+		if err != nil || len(n.GetActions()) > 0 {
+			return &n, err
+		}
+	}
+	s.completed(result, eventUrl, leg, start, "user")
+	return nil, nil
+}
+
+func (s *Simulator) callEvent(result *Result, uuid string, status string) voicewebhook.CallEvent {
+	return voicewebhook.CallEvent{
+		Uuid:             uuid,
+		ConversationUuid: result.ConversationUuid,
+		Status:           status,
+		Direction:        s.Direction,
+		To:               s.To,
+		From:             s.From,
+		Timestamp:        s.Now(),
+	}
+}
+
+func (s *Simulator) callEvents(result *Result, eventUrl string, uuid string, statuses ...string) {
+	for _, status := range statuses {
+		var event voicewebhook.Event = s.callEvent(result, uuid, status)
+		if status == "answered" {
+			event = voicewebhook.AnsweredEvent{CallEvent: s.callEvent(result, uuid, status), StartTime: s.Now()}
+		}
+		s.send(result, eventUrl, event)
+	}
+}
+
+func (s *Simulator) completed(result *Result, eventUrl string, uuid string, start time.Time, disconnectedBy string) {
+	end := s.Now()
+	s.send(result, eventUrl, voicewebhook.CompletedEvent{
+		CallEvent:      s.callEvent(result, uuid, "completed"),
+		StartTime:      start,
+		EndTime:        end,
+		Duration:       int(end.Sub(start).Seconds()),
+		DisconnectedBy: disconnectedBy,
+	})
+}
+
+// send records the event and posts it if there is an event URL, the
+// response to status events is ignored as it is by Vonage
+func (s *Simulator) send(result *Result, eventUrl string, event voicewebhook.Event) {
+	result.Events = append(result.Events, event)
+	if eventUrl != "" {
+		s.post(eventUrl, event)
+	}
+}
+
+func (s *Simulator) post(target string, body interface{}) (ncco.Ncco, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return ncco.Ncco{}, err
+	}
+	return s.request("POST", target, data)
+}
+
+// request serves the webhook with Handler and reads any NCCO returned
+func (s *Simulator) request(method string, target string, body []byte) (ncco.Ncco, error) {
+	var n ncco.Ncco
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	s.Handler.ServeHTTP(rec, req)
+
+	if rec.Code >= 300 {
+		return n, fmt.Errorf("Webhook %s %s returned %d: %s", method, target, rec.Code, rec.Body.String())
+	}
+	response := bytes.TrimSpace(rec.Body.Bytes())
+	if len(response) == 0 || string(response) == "null" {
+		return n, nil
+	}
+	if err := json.Unmarshal(response, &n); err != nil {
+		return n, fmt.Errorf("Webhook %s %s did not return an NCCO: %s", method, target, err)
+	}
+	return n, nil
+}
+
+func firstUrl(urls []string, fallback string) string {
+	if len(urls) > 0 {
+		return urls[0]
+	}
+	return fallback
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, item := range haystack {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
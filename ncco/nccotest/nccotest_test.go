@@ -0,0 +1,139 @@
+package nccotest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vonage/vonage-go-sdk/ivr"
+	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+func testMenu(t *testing.T) *ivr.IVR {
+	menu, err := ivr.New("https://example.com/ivr", "main",
+		ivr.Node{
+			ID:            "main",
+			Prompt:        []ncco.Action{ncco.TalkAction{Text: "Press 1 for sales"}},
+			Input:         ncco.InputAction{Dtmf: &ncco.DtmfInput{MaxDigits: 1}, Speech: &ncco.SpeechInput{}},
+			Branches:      map[string]string{"1": "sales", "sales": "sales"},
+			Retries:       1,
+			InvalidPrompt: []ncco.Action{ncco.TalkAction{Text: "Sorry"}},
+		},
+		ivr.Node{
+			ID:     "sales",
+			Prompt: []ncco.Action{ncco.TalkAction{Text: "Connecting you"}, ncco.ConnectAction{Endpoint: []ncco.Endpoint{ncco.PhoneEndpoint{Number: "447700900002"}}}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Menu should be valid: %s", err)
+	}
+	return menu
+}
+
+func TestSimulatorIVR(t *testing.T) {
+	sim := Simulator{
+		Handler:   testMenu(t),
+		AnswerUrl: "https://example.com/ivr/answer",
+		EventUrl:  "https://example.com/ivr/event",
+		To:        "447700900000",
+		From:      "447700900001",
+		Inputs:    []Input{Digits("9"), Speech("sales")},
+	}
+
+	result, err := sim.Run()
+	if err != nil {
+		t.Fatalf("Call flow should run: %s", err)
+	}
+
+	if strings.Join(result.Talk, "|") != "Press 1 for sales|Sorry|Press 1 for sales|Connecting you" {
+		t.Errorf("Unexpected talk: %v", result.Talk)
+	}
+	expected := "started,ringing,answered,input,input,started,ringing,answered,completed,completed"
+	if strings.Join(result.EventTypes(), ",") != expected {
+		t.Errorf("Unexpected events: %v", result.EventTypes())
+	}
+	if result.DisconnectedBy != "platform" {
+		t.Errorf("Unexpected hangup: %s", result.DisconnectedBy)
+	}
+
+	input := result.Events[4].(voicewebhook.InputEvent)
+	if best, _ := input.BestTranscript(); best.Text != "sales" || input.Dtmf.TimedOut {
+		t.Errorf("Unexpected input event: %+v", input)
+	}
+}
+
+func TestSimulatorTimeoutAndHangup(t *testing.T) {
+	sim := Simulator{
+		Handler:   testMenu(t),
+		AnswerUrl: "https://example.com/ivr/answer",
+	}
+	result, err := sim.Run()
+	if err != nil {
+		t.Fatalf("Call flow should run: %s", err)
+	}
+	if len(result.Talk) != 3 || result.Talk[2] != "Press 1 for sales" {
+		t.Errorf("Input should time out twice then end: %v", result.Talk)
+	}
+	input := result.Events[3].(voicewebhook.InputEvent)
+	if !input.TimedOut() {
+		t.Errorf("Input without a script should time out: %+v", input)
+	}
+
+	sim = Simulator{Handler: testMenu(t), AnswerUrl: "https://example.com/ivr/answer", HangupAfter: 1, MachineDetection: "machine"}
+	result, _ = sim.Run()
+	if result.DisconnectedBy != "user" || len(result.Actions) != 1 {
+		t.Errorf("Caller should hang up after one action: %+v", result)
+	}
+	if strings.Join(result.EventTypes(), ",") != "started,ringing,answered,machine,completed" {
+		t.Errorf("Unexpected events: %v", result.EventTypes())
+	}
+}
+
+func TestSimulatorRunNcco(t *testing.T) {
+	var notified map[string]string
+	var recorded voicewebhook.RecordEvent
+	mux := http.NewServeMux()
+	mux.HandleFunc("/notify", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &notified)
+		w.Write([]byte(`[{"action":"talk","text":"Notified"}]`))
+	})
+	mux.HandleFunc("/record", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		event, _ := voicewebhook.ParseEvent(body)
+		recorded = event.(voicewebhook.RecordEvent)
+	})
+
+	n := ncco.Ncco{}
+	n.AddAction(ncco.RecordAction{EventUrl: []string{"https://example.com/record"}})
+	n.AddAction(ncco.NotifyAction{EventUrl: []string{"https://example.com/notify"}, Payload: map[string]string{"step": "1"}})
+	n.AddAction(ncco.TalkAction{Text: "Never heard"})
+
+	clock := time.Date(2020, 3, 10, 15, 0, 0, 0, time.UTC)
+	sim := Simulator{Handler: mux, Now: func() time.Time { return clock }}
+	result, err := sim.RunNcco(n)
+	if err != nil {
+		t.Fatalf("Call flow should run: %s", err)
+	}
+
+	if notified["step"] != "1" {
+		t.Errorf("Notify payload not sent: %v", notified)
+	}
+	if len(result.Talk) != 1 || result.Talk[0] != "Notified" {
+		t.Errorf("Notify response should replace the NCCO: %v", result.Talk)
+	}
+	if recorded.RecordingUrl == "" || !recorded.Timestamp.Equal(clock) {
+		t.Errorf("Record event not sent: %+v", recorded)
+	}
+}
+
+func TestSimulatorWebhookError(t *testing.T) {
+	sim := Simulator{Handler: http.NotFoundHandler(), AnswerUrl: "https://example.com/answer"}
+	if _, err := sim.Run(); err == nil {
+		t.Errorf("Answer URL errors should fail the run")
+	}
+}
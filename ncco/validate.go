@@ -0,0 +1,271 @@
+package ncco
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vonage/vonage-go-sdk/tts"
+)
+
+// Limits checked by Validate
+const (
+	TalkTextMaxLength       = tts.TextMaxLength
+	ConversationNameMaxSize = 100
+)
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4}){1,2}$`)
+
+// ValidationError is one problem found by Validate. Index is the position
+// of the action in the NCCO, or -1 for problems with the NCCO as a whole
+type ValidationError struct {
+	Index   int
+	Action  string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Index < 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("action %d (%s): %s", e.Index, e.Action, e.Message)
+}
+
+// ValidationErrors holds every problem found by Validate
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validator is implemented by the actions, returning every problem found
+type validator interface {
+	validate() []string
+}
+
+// Validate checks the NCCO before it is used, returning ValidationErrors
+// with all the problems found, or nil if there are none
+func (n *Ncco) Validate() error {
+	var problems ValidationErrors
+	actions := n.GetActions()
+	if len(actions) == 0 {
+		problems = append(problems, ValidationError{Index: -1, Message: "NCCO has no actions"})
+	}
+
+	terminal := -1
+	for i, action := range actions {
+		name := actionName(action)
+		if terminal >= 0 {
+			problems = append(problems, ValidationError{Index: i, Action: name, Message: fmt.Sprintf("Action will not run, the NCCO from action %d's eventUrl replaces it", terminal)})
+		}
+
+		if v, ok := action.(validator); ok {
+			for _, message := range v.validate() {
+				problems = append(problems, ValidationError{Index: i, Action: name, Message: message})
+			}
+		}
+
+		if terminal < 0 && replacesNcco(action) {
+			terminal = i
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// replacesNcco is true for actions whose eventUrl returns the rest of
+// the NCCO, so any actions after them are never run
+func replacesNcco(action interface{}) bool {
+	switch a := action.(type) {
+	case InputAction:
+		return true
+	case ConnectAction:
+		return a.EventType == "synchronous"
+	}
+	return false
+}
+
+func actionName(action interface{}) string {
+	switch a := action.(type) {
+	case TalkAction:
+		return a.Action
+	case NotifyAction:
+		return a.Action
+	case RecordAction:
+		return a.Action
+	case ConversationAction:
+		return a.Action
+	case StreamAction:
+		return a.Action
+	case InputAction:
+		return a.Action
+	case ConnectAction:
+		return a.Action
+	}
+	return "unknown"
+}
+
+func validateLevel(level int) []string {
+	if level < -1 || level > 1 {
+		return []string{"Level must be between -1 and 1"}
+	}
+	return nil
+}
+
+func validateEventMethod(method string) []string {
+	if method != "" && method != "GET" && method != "POST" {
+		return []string{"EventMethod must be GET or POST"}
+	}
+	return nil
+}
+
+func (a TalkAction) validate() []string {
+	var problems []string
+	if a.Text == "" {
+		problems = append(problems, "Talk needs Text")
+	}
+	if len([]rune(a.Text)) > TalkTextMaxLength {
+		problems = append(problems, fmt.Sprintf("Talk Text is longer than %d characters", TalkTextMaxLength))
+	}
+	if tts.IsSSML(a.Text) {
+		if err := tts.ValidateSSML(a.Text); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if a.VoiceName != "" {
+		if _, ok := tts.VoiceNameLanguage(a.VoiceName); !ok {
+			problems = append(problems, fmt.Sprintf("Unknown VoiceName %q", a.VoiceName))
+		}
+		if a.Language != "" {
+			problems = append(problems, "Use Language and Style instead of VoiceName, not both")
+		}
+	}
+	if a.Language != "" {
+		if err := (tts.Voice{Language: a.Language, Style: a.Style}).Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	} else if a.Style != 0 || a.Premium {
+		problems = append(problems, "Style and Premium need a Language")
+	}
+	if a.CalculatedLoopValue < 0 {
+		problems = append(problems, "Loop can't be negative")
+	}
+	return append(problems, validateLevel(a.Level)...)
+}
+
+func (a StreamAction) validate() []string {
+	var problems []string
+	if len(a.StreamUrl) != 1 || a.StreamUrl[0] == "" {
+		problems = append(problems, "Stream needs exactly one StreamUrl")
+	}
+	if a.CalculatedLoopValue < 0 {
+		problems = append(problems, "Loop can't be negative")
+	}
+	return append(problems, validateLevel(a.Level)...)
+}
+
+func (a NotifyAction) validate() []string {
+	var problems []string
+	if len(a.EventUrl) == 0 {
+		problems = append(problems, "Notify needs an EventUrl")
+	}
+	return append(problems, validateEventMethod(a.EventMethod)...)
+}
+
+func (a RecordAction) validate() []string {
+	var problems []string
+	if a.Format != "" && a.Format != "mp3" && a.Format != "wav" && a.Format != "ogg" {
+		problems = append(problems, "Record Format must be mp3, wav or ogg")
+	}
+	if a.Split != "" && a.Split != "conversation" {
+		problems = append(problems, "Record Split must be conversation")
+	}
+	if a.Channels < 0 || a.Channels > 32 {
+		problems = append(problems, "Record Channels must be between 0 and 32, 0 for the default")
+	}
+	if a.Channels > 1 && a.Split != "conversation" {
+		problems = append(problems, "Recording more than one channel needs Split set to conversation")
+	}
+	if a.EndOnSilence != 0 && (a.EndOnSilence < 3 || a.EndOnSilence > 10) {
+		problems = append(problems, "Record EndOnSilence must be between 3 and 10 seconds")
+	}
+	if a.TimeOut != 0 && (a.TimeOut < 3 || a.TimeOut > 7200) {
+		problems = append(problems, "Record TimeOut must be between 3 and 7200 seconds")
+	}
+	if a.Transcription != nil && a.Transcription.Language != "" && !languageCode.MatchString(a.Transcription.Language) {
+		problems = append(problems, fmt.Sprintf("Transcription Language %q is not a language code like en-GB", a.Transcription.Language))
+	}
+	if a.EndOnKey != "" && (len(a.EndOnKey) > 1 || !strings.Contains("0123456789*#", a.EndOnKey)) {
+		problems = append(problems, "Record EndOnKey must be a single digit, * or #")
+	}
+	return append(problems, validateEventMethod(a.EventMethod)...)
+}
+
+func (a ConversationAction) validate() []string {
+	var problems []string
+	if a.Name == "" {
+		problems = append(problems, "Conversation needs a Name")
+	}
+	if len(a.Name) > ConversationNameMaxSize {
+		problems = append(problems, fmt.Sprintf("Conversation Name is longer than %d characters", ConversationNameMaxSize))
+	}
+	if len(a.MusicOnHoldUrl) > 1 {
+		problems = append(problems, "Conversation takes one MusicOnHoldUrl")
+	}
+	return problems
+}
+
+func (a InputAction) validate() []string {
+	var problems []string
+	if len(a.EventUrl) == 0 {
+		problems = append(problems, "Input needs an EventUrl to send the results to")
+	}
+	if a.Dtmf == nil && a.Speech == nil {
+		problems = append(problems, "Input needs Dtmf or Speech settings")
+	}
+	for _, t := range a.Type {
+		if t != "dtmf" && t != "speech" {
+			problems = append(problems, fmt.Sprintf("Unknown input Type %q", t))
+		}
+	}
+	if a.Dtmf != nil {
+		if a.Dtmf.MaxDigits < 0 || a.Dtmf.MaxDigits > 20 {
+			problems = append(problems, "Dtmf MaxDigits must be between 0 and 20, 0 for the default")
+		}
+		if a.Dtmf.TimeOut < 0 || a.Dtmf.TimeOut > 10 {
+			problems = append(problems, "Dtmf TimeOut must be between 0 and 10 seconds")
+		}
+	}
+	if a.Speech != nil {
+		if a.Speech.Language != "" && !languageCode.MatchString(a.Speech.Language) {
+			problems = append(problems, fmt.Sprintf("Speech Language %q is not a language code like en-GB", a.Speech.Language))
+		}
+		if a.Speech.Sensitivity != nil && (*a.Speech.Sensitivity < 0 || *a.Speech.Sensitivity > 100) {
+			problems = append(problems, "Speech Sensitivity must be between 0 and 100")
+		}
+		if a.Speech.MaxDuration < 0 || a.Speech.MaxDuration > 60 {
+			problems = append(problems, "Speech MaxDuration must be between 0 and 60 seconds, 0 for the default")
+		}
+	}
+	return append(problems, validateEventMethod(a.EventMethod)...)
+}
+
+func (a ConnectAction) validate() []string {
+	var problems []string
+	if len(a.Endpoint) == 0 {
+		problems = append(problems, "Connect action needs at least one Endpoint")
+	}
+	for i, endpoint := range a.Endpoint {
+		if err := endpoint.validateEndpoint(); err != nil {
+			problems = append(problems, fmt.Sprintf("Endpoint %d: %s", i, err))
+		}
+	}
+	return append(problems, validateEventMethod(a.EventMethod)...)
+}
@@ -0,0 +1,111 @@
+package ncco
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNccoValidateValid(t *testing.T) {
+	ncco := Ncco{}
+	ncco.AddAction(TalkAction{Text: "Hello", VoiceName: "Amy"})
+	ncco.AddAction(RecordAction{Format: "wav", Split: "conversation", Channels: 2, EndOnKey: "#"})
+	ncco.AddAction(StreamAction{StreamUrl: []string{"https://example.com/music.mp3"}})
+	ncco.AddAction(InputAction{EventUrl: []string{"https://example.com/input"}, Speech: &SpeechInput{Language: "en-GB"}})
+
+	if err := ncco.Validate(); err != nil {
+		t.Errorf("NCCO should be valid: %s", err)
+	}
+}
+
+func TestNccoValidateAllProblems(t *testing.T) {
+	ncco := Ncco{}
+	ncco.AddAction(TalkAction{Text: strings.Repeat("a", 1501), Level: 2})
+	ncco.AddAction(StreamAction{})
+	ncco.AddAction(RecordAction{Format: "flac", Channels: 2})
+	ncco.AddAction(ConversationAction{})
+	ncco.AddAction(InputAction{Dtmf: &DtmfInput{MaxDigits: 1}, Speech: &SpeechInput{Language: "english"}})
+	ncco.AddAction(ConnectAction{Endpoint: []Endpoint{AppEndpoint{}}})
+
+	err := ncco.Validate()
+	problems, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	expected := []string{
+		"action 0 (talk): Talk Text is longer than 1500 characters",
+		"action 0 (talk): Level must be between -1 and 1",
+		"action 1 (stream): Stream needs exactly one StreamUrl",
+		"action 2 (record): Record Format must be mp3, wav or ogg",
+		"action 2 (record): Recording more than one channel needs Split set to conversation",
+		"action 3 (conversation): Conversation needs a Name",
+		"action 4 (input): Input needs an EventUrl to send the results to",
+		"action 4 (input): Speech Language \"english\" is not a language code like en-GB",
+		"action 5 (connect): Action will not run, the NCCO from action 4's eventUrl replaces it",
+		"action 5 (connect): Endpoint 0: App endpoints need a User",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Unexpected problems: %s", err)
+	}
+	for i, message := range expected {
+		if problems[i].Error() != message {
+			t.Errorf("Unexpected problem %d: %s", i, problems[i])
+		}
+	}
+	if problems[9].Index != 5 || problems[9].Action != "connect" {
+		t.Errorf("Unexpected problem details: %+v", problems[9])
+	}
+}
+
+func TestNccoValidateRanges(t *testing.T) {
+	// zero leaves each setting to the Voice API's default
+	defaults := InputAction{EventUrl: []string{"https://example.com/input"}, Dtmf: &DtmfInput{}, Speech: &SpeechInput{}}
+	if problems := append(defaults.validate(), RecordAction{}.validate()...); len(problems) != 0 {
+		t.Errorf("Zero settings should be valid: %v", problems)
+	}
+
+	input := InputAction{EventUrl: []string{"https://example.com/input"}, Dtmf: &DtmfInput{MaxDigits: 21}, Speech: &SpeechInput{MaxDuration: 61}}
+	problems := append(input.validate(), RecordAction{Channels: 33, Split: "conversation"}.validate()...)
+	expected := []string{
+		"Dtmf MaxDigits must be between 0 and 20, 0 for the default",
+		"Speech MaxDuration must be between 0 and 60 seconds, 0 for the default",
+		"Record Channels must be between 0 and 32, 0 for the default",
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected range problems: %v", problems)
+	}
+}
+
+func TestNccoValidateEmpty(t *testing.T) {
+	ncco := Ncco{}
+	err := ncco.Validate()
+	if err == nil || err.Error() != "NCCO has no actions" {
+		t.Errorf("Empty NCCO should be invalid: %v", err)
+	}
+}
+
+func TestNccoValidateTalkVoice(t *testing.T) {
+	valid := TalkAction{Text: "<speak>Hello <break time=\"1s\"/></speak>", Language: "en-US", Style: 10, Premium: true}
+	if problems := valid.validate(); len(problems) != 0 {
+		t.Errorf("Talk with a language and style should be valid: %v", problems)
+	}
+	newStyle := TalkAction{Text: "Hello", Language: "cy-GB", Style: 1}
+	if problems := newStyle.validate(); len(problems) != 0 {
+		t.Errorf("Styles without a voice name should be left to the API: %v", problems)
+	}
+
+	invalid := map[string]TalkAction{
+		"language":        {Text: "Hello", Language: "en-XX"},
+		"style":           {Text: "Hello", Language: "cy-GB", Style: -1},
+		"voice name":      {Text: "Hello", VoiceName: "Nicola"},
+		"both":            {Text: "Hello", VoiceName: "Amy", Language: "en-GB"},
+		"style only":      {Text: "Hello", Style: 2},
+		"unclosed ssml":   {Text: "<speak>Hello <emphasis>there</speak>"},
+		"ssml long break": {Text: "<speak><break time=\"20s\"/></speak>"},
+	}
+	for name, talk := range invalid {
+		if problems := talk.validate(); len(problems) == 0 {
+			t.Errorf("Talk with invalid %s should have problems", name)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package tts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxBreak is the longest pause an SSML break can make
+const MaxBreak = 10 * time.Second
+
+// the values the SSML attributes accept, as well as those matched by the
+// patterns below
+var (
+	breakStrengths  = []string{"none", "x-weak", "weak", "medium", "strong", "x-strong"}
+	prosodyRates    = []string{"x-slow", "slow", "medium", "fast", "x-fast", "default"}
+	prosodyPitches  = []string{"x-low", "low", "medium", "high", "x-high", "default"}
+	prosodyVolumes  = []string{"silent", "x-soft", "soft", "medium", "loud", "x-loud", "default"}
+	emphasisLevels  = []string{"strong", "moderate", "reduced"}
+	sayAsInterprets = []string{"characters", "spell-out", "cardinal", "number", "ordinal", "digits", "fraction", "unit", "date", "time", "address", "expletive", "telephone"}
+	ssmlElements    = []string{"speak", "break", "prosody", "say-as", "emphasis", "p", "s", "sub", "lang", "phoneme", "w"}
+)
+
+var breakTime = regexp.MustCompile(`^([0-9]+)(ms|s)$`)
+var prosodyRate = regexp.MustCompile(`^[0-9]+%$`)
+var prosodyPitch = regexp.MustCompile(`^[+-][0-9]+(\.[0-9]+)?%$`)
+var prosodyVolume = regexp.MustCompile(`^[+-][0-9]+(\.[0-9]+)?dB$`)
+
+// Prosody changes the rate, pitch or volume of some text. Rate is a named
+// speed like "slow" or a percentage like "80%", Pitch is a named pitch
+// like "high" or a change like "+10%", and Volume is a named volume like
+// "loud" or a change like "-6dB"
+type Prosody struct {
+	Rate   string
+	Pitch  string
+	Volume string
+}
+
+// SSML builds text marked up with Speech Synthesis Markup Language, to use
+// as the Text of a talk action or PlayTts. Add each piece in the order it
+// should be spoken, then call Build to get the validated text
+type SSML struct {
+	parts []string
+}
+
+// Text adds plain text, any characters that are special in XML are escaped
+func (s *SSML) Text(text string) *SSML {
+	s.parts = append(s.parts, escape(text))
+	return s
+}
+
+// Break adds a pause of up to ten seconds
+func (s *SSML) Break(pause time.Duration) *SSML {
+	s.parts = append(s.parts, fmt.Sprintf(`<break time="%dms"/>`, pause/time.Millisecond))
+	return s
+}
+
+// BreakStrength adds a pause sized like the pause between words, clauses,
+// sentences or paragraphs, from "x-weak" to "x-strong"
+func (s *SSML) BreakStrength(strength string) *SSML {
+	s.parts = append(s.parts, `<break strength="`+escape(strength)+`"/>`)
+	return s
+}
+
+// Prosody adds text spoken with a different rate, pitch or volume
+func (s *SSML) Prosody(text string, prosody Prosody) *SSML {
+	tag := "<prosody"
+	if prosody.Rate != "" {
+		tag += ` rate="` + escape(prosody.Rate) + `"`
+	}
+	if prosody.Pitch != "" {
+		tag += ` pitch="` + escape(prosody.Pitch) + `"`
+	}
+	if prosody.Volume != "" {
+		tag += ` volume="` + escape(prosody.Volume) + `"`
+	}
+	s.parts = append(s.parts, tag+">"+escape(text)+"</prosody>")
+	return s
+}
+
+// SayAs adds text that should be read in a particular way, interpretAs is
+// one of the say-as types such as "digits", "characters" or "telephone"
+func (s *SSML) SayAs(text string, interpretAs string) *SSML {
+	s.parts = append(s.parts, `<say-as interpret-as="`+escape(interpretAs)+`">`+escape(text)+"</say-as>")
+	return s
+}
+
+// SayAsFormat is SayAs with a format, such as "dmy" for a date
+func (s *SSML) SayAsFormat(text string, interpretAs string, format string) *SSML {
+	s.parts = append(s.parts, `<say-as interpret-as="`+escape(interpretAs)+`" format="`+escape(format)+`">`+escape(text)+"</say-as>")
+	return s
+}
+
+// Emphasis adds text spoken with "strong", "moderate" or "reduced" emphasis
+func (s *SSML) Emphasis(text string, level string) *SSML {
+	s.parts = append(s.parts, `<emphasis level="`+escape(level)+`">`+escape(text)+"</emphasis>")
+	return s
+}
+
+// String returns the SSML without validating it
+func (s *SSML) String() string {
+	return "<speak>" + strings.Join(s.parts, "") + "</speak>"
+}
+
+// Build returns the SSML, or an error if any of the values used aren't
+// supported or it is too long to be spoken
+func (s *SSML) Build() (string, error) {
+	text := s.String()
+	if err := ValidateSSML(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// IsSSML is true if the text is SSML rather than plain text
+func IsSSML(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "<speak>")
+}
+
+// ValidateSSML checks that SSML text is well formed, is within
+// TextMaxLength and uses only the tags and attribute values that the
+// Voice API supports
+func ValidateSSML(text string) error {
+	if len([]rune(text)) > TextMaxLength {
+		return fmt.Errorf("SSML is longer than %d characters", TextMaxLength)
+	}
+	if !IsSSML(text) {
+		return errors.New("SSML must be inside a <speak> tag")
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("SSML is not well formed: %s", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if err := validateElement(start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// validateElement checks the attributes of one SSML tag
+func validateElement(e xml.StartElement) error {
+	name := e.Name.Local
+	if !contains(ssmlElements, name) {
+		return fmt.Errorf("Unsupported SSML tag <%s>", name)
+	}
+
+	for _, attr := range e.Attr {
+		value := attr.Value
+		valid := true
+		switch name + " " + attr.Name.Local {
+		case "break time":
+			valid = validBreak(value)
+		case "break strength":
+			valid = contains(breakStrengths, value)
+		case "prosody rate":
+			valid = contains(prosodyRates, value) || prosodyRate.MatchString(value)
+		case "prosody pitch":
+			valid = contains(prosodyPitches, value) || prosodyPitch.MatchString(value)
+		case "prosody volume":
+			valid = contains(prosodyVolumes, value) || prosodyVolume.MatchString(value)
+		case "say-as interpret-as":
+			valid = contains(sayAsInterprets, value)
+		case "emphasis level":
+			valid = contains(emphasisLevels, value)
+		}
+		if !valid {
+			return fmt.Errorf("Unsupported value %q for %s in <%s>", value, attr.Name.Local, name)
+		}
+	}
+	return nil
+}
+
+// validBreak checks a break time like "500ms" or "2s" is no more than
+// MaxBreak
+func validBreak(value string) bool {
+	match := breakTime.FindStringSubmatch(value)
+	if match == nil {
+		return false
+	}
+	amount, err := strconv.Atoi(match[1])
+	if err != nil {
+		return false
+	}
+	unit := time.Millisecond
+	if match[2] == "s" {
+		unit = time.Second
+	}
+	return time.Duration(amount)*unit <= MaxBreak
+}
+
+// escape replaces the characters that are special in XML
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
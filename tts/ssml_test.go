@@ -0,0 +1,51 @@
+package tts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSMLBuilder(t *testing.T) {
+	ssml := SSML{}
+	ssml.Text("Your code is ").
+		SayAs("1234", "digits").
+		Break(500*time.Millisecond).
+		Prosody("Goodbye & thanks", Prosody{Rate: "slow", Pitch: "+10%", Volume: "-6dB"}).
+		Emphasis("now", "strong").
+		SayAsFormat("01/02/2020", "date", "dmy").
+		BreakStrength("x-strong")
+
+	text, err := ssml.Build()
+	if err != nil {
+		t.Fatalf("SSML should be valid: %s", err)
+	}
+	expected := `<speak>Your code is <say-as interpret-as="digits">1234</say-as><break time="500ms"/><prosody rate="slow" pitch="+10%" volume="-6dB">Goodbye &amp; thanks</prosody><emphasis level="strong">now</emphasis><say-as interpret-as="date" format="dmy">01/02/2020</say-as><break strength="x-strong"/></speak>`
+	if text != expected {
+		t.Errorf("Unexpected SSML: %s", text)
+	}
+}
+
+func TestSSMLValidation(t *testing.T) {
+	invalid := map[string]*SSML{
+		"break":    (&SSML{}).Break(11 * time.Second),
+		"strength": (&SSML{}).BreakStrength("huge"),
+		"rate":     (&SSML{}).Prosody("Hi", Prosody{Rate: "quick"}),
+		"pitch":    (&SSML{}).Prosody("Hi", Prosody{Pitch: "10%"}),
+		"volume":   (&SSML{}).Prosody("Hi", Prosody{Volume: "+6"}),
+		"say-as":   (&SSML{}).SayAs("Hi", "shout"),
+		"emphasis": (&SSML{}).Emphasis("Hi", "loud"),
+		"length":   (&SSML{}).Text(strings.Repeat("a", TextMaxLength)),
+	}
+	for name, ssml := range invalid {
+		if _, err := ssml.Build(); err == nil {
+			t.Errorf("SSML with invalid %s should fail", name)
+		}
+	}
+
+	for _, text := range []string{"Hello", "<speak>Hello", "<speak><audio src=\"x\"/></speak>"} {
+		if ValidateSSML(text) == nil {
+			t.Errorf("SSML should be invalid: %s", text)
+		}
+	}
+}
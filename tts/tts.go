@@ -0,0 +1,133 @@
+// Package tts lists the languages the Voice API's text-to-speech speaks,
+// with the deprecated voice names for each, and builds SSML for talk
+// actions and PlayTts. The styles each language has are listed at
+// https://developer.nexmo.com/voice/voice-api/guides/text-to-speech
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// TextMaxLength is the most characters of text, including SSML tags, that
+// can be spoken in one talk action or PlayTts request
+const TextMaxLength = 1500
+
+// Language is a text-to-speech language and its deprecated voice names
+type Language struct {
+	Code       string
+	Name       string
+	VoiceNames []string
+}
+
+// Voice is the language and style to speak with, the replacement for a
+// voice name. Premium uses the premium version of the style
+type Voice struct {
+	Language string
+	Style    int
+	Premium  bool
+}
+
+// Validate checks the language is one the Voice API speaks and the style
+// isn't negative. The styles each language has change as Vonage adds
+// them, so they are left for the Voice API to check
+func (v Voice) Validate() error {
+	if v.Language == "" {
+		return errors.New("Voice needs a Language")
+	}
+	if _, ok := Lookup(v.Language); !ok {
+		return fmt.Errorf("Unsupported text-to-speech Language %q", v.Language)
+	}
+	if v.Style < 0 {
+		return fmt.Errorf("Style can't be negative, got %d", v.Style)
+	}
+	return nil
+}
+
+// languages lists the deprecated voice names for each language
+var languages = []struct {
+	code   string
+	name   string
+	voices []string
+}{
+	{"ar", "Arabic", []string{"Laila", "Maged", "Tarik", "Zeina"}},
+	{"ca-ES", "Catalan", []string{"Empar", "Jordi", "Montserrat"}},
+	{"cs-CZ", "Czech", []string{"Iveta", "Zuzana"}},
+	{"cy-GB", "Welsh", []string{"Gwyneth"}},
+	{"da-DK", "Danish", []string{"Mads", "Naja"}},
+	{"de-DE", "German", []string{"Hans", "Marlene", "Vicki"}},
+	{"el-GR", "Greek", []string{"Melina", "Nikos"}},
+	{"en-AU", "English (Australia)", []string{"Nicole", "Russell"}},
+	{"en-GB", "English (United Kingdom)", []string{"Amy", "Brian", "Emma"}},
+	{"en-GB-WLS", "English (Wales)", []string{"Geraint"}},
+	{"en-IN", "English (India)", []string{"Aditi", "Raveena"}},
+	{"en-US", "English (United States)", []string{"Chipmunk", "Eric", "Ivy", "Jennifer", "Joanna", "Joey", "Justin", "Kendra", "Kimberly", "Matthew", "Salli"}},
+	{"en-ZA", "English (South Africa)", []string{"Tessa"}},
+	{"es-ES", "Spanish (Spain)", []string{"Conchita", "Enrique", "Lucia"}},
+	{"es-MX", "Spanish (Mexico)", []string{"Mia"}},
+	{"es-US", "Spanish (United States)", []string{"Miguel", "Penelope"}},
+	{"eu-ES", "Basque", []string{"Miren"}},
+	{"fi-FI", "Finnish", []string{"Satu"}},
+	{"fr-CA", "French (Canada)", []string{"Chantal"}},
+	{"fr-FR", "French", []string{"Celine", "Lea", "Mathieu"}},
+	{"he-IL", "Hebrew", []string{"Carmit"}},
+	{"hi-IN", "Hindi", []string{"Lekha"}},
+	{"hu-HU", "Hungarian", []string{"Mariska"}},
+	{"id-ID", "Indonesian", []string{"Damayanti"}},
+	{"is-IS", "Icelandic", []string{"Dora", "Karl"}},
+	{"it-IT", "Italian", []string{"Bianca", "Carla", "Giorgio"}},
+	{"ja-JP", "Japanese", []string{"Mizuki", "Takumi"}},
+	{"ko-KR", "Korean", []string{"Seoyeon", "Sora"}},
+	{"nb-NO", "Norwegian", []string{"Henrik", "Liv", "Nora"}},
+	{"nl-NL", "Dutch", []string{"Lotte", "Ruben"}},
+	{"pl-PL", "Polish", []string{"Agnieszka", "Ewa", "Jacek", "Jan", "Maja"}},
+	{"pt-BR", "Portuguese (Brazil)", []string{"Catarina", "Felipe", "Luciana", "Ricardo", "Vitoria"}},
+	{"pt-PT", "Portuguese (Portugal)", []string{"Cristiano", "Ines", "Joana"}},
+	{"ro-RO", "Romanian", []string{"Carmen", "Ioana"}},
+	{"ru-RU", "Russian", []string{"Maxim", "Tatyana"}},
+	{"sk-SK", "Slovak", []string{"Laura"}},
+	{"sv-SE", "Swedish", []string{"Alva", "Astrid", "Oskar"}},
+	{"th-TH", "Thai", []string{"Kanya"}},
+	{"tr-TR", "Turkish", []string{"Cem", "Filiz", "Yelda"}},
+	{"yue-CN", "Cantonese", []string{"Sin-Ji"}},
+	{"zh-CN", "Chinese (Mandarin)", []string{"Tian-Tian", "Zhiyu"}},
+	{"zh-TW", "Chinese (Taiwanese Mandarin)", []string{"Mei-Jia"}},
+}
+
+// byCode and byVoiceName index the languages
+var byCode = map[string]Language{}
+var byVoiceName = map[string]string{}
+
+func init() {
+	for _, l := range languages {
+		byCode[l.code] = Language{Code: l.code, Name: l.name, VoiceNames: l.voices}
+		for _, name := range l.voices {
+			byVoiceName[name] = l.code
+		}
+	}
+}
+
+// Languages returns every language, sorted by code
+func Languages() []Language {
+	list := make([]Language, 0, len(byCode))
+	for _, language := range byCode {
+		list = append(list, language)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Lookup finds a language by its code, such as "en-GB"
+func Lookup(code string) (Language, bool) {
+	language, ok := byCode[code]
+	return language, ok
+}
+
+// VoiceNameLanguage returns the language code of one of the deprecated
+// voice names, such as "en-GB" for "Amy". Pick the style for it from the
+// styles Vonage lists for the language
+func VoiceNameLanguage(name string) (string, bool) {
+	code, ok := byVoiceName[name]
+	return code, ok
+}
@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"testing"
+)
+
+func TestVoiceNameLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Amy":     "en-GB",
+		"Russell": "en-AU",
+		"Mei-Jia": "zh-TW",
+		"Salli":   "en-US",
+	}
+	for name, expected := range cases {
+		code, ok := VoiceNameLanguage(name)
+		if !ok || code != expected {
+			t.Errorf("Unexpected language for %s: %s", name, code)
+		}
+	}
+
+	if _, ok := VoiceNameLanguage("Nicola"); ok {
+		t.Errorf("Unknown voice names should not be found")
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	if len(languages) != len(byCode) || languages[0].Code != "ar" {
+		t.Errorf("Languages should be sorted by code: %s", languages[0].Code)
+	}
+
+	voices := 0
+	for _, language := range languages {
+		voices += len(language.VoiceNames)
+	}
+	if voices != len(byVoiceName) {
+		t.Errorf("Each voice name should belong to exactly one language: %d voices for %d names", voices, len(byVoiceName))
+	}
+
+	welsh, _ := Lookup("cy-GB")
+	if welsh.Name != "Welsh" || len(welsh.VoiceNames) != 1 {
+		t.Errorf("Unexpected Welsh language: %+v", welsh)
+	}
+}
+
+func TestVoiceValidate(t *testing.T) {
+	if err := (Voice{Language: "en-GB", Style: 3}).Validate(); err != nil {
+		t.Errorf("Styles should be left to the API: %s", err)
+	}
+	invalid := map[string]Voice{
+		"unknown language": {Language: "en-XX"},
+		"no language":      {Style: 1},
+		"negative style":   {Language: "en-GB", Style: -1},
+	}
+	for name, voice := range invalid {
+		if voice.Validate() == nil {
+			t.Errorf("Voice with %s should be invalid", name)
+		}
+	}
+}
@@ -10,3 +10,13 @@ func GetUserAgent() string {
 	user_agent := "vonage-go/" + GetVersion() + " Go/" + runtime.Version()
 	return user_agent
 }
+
+// containsString checks whether a value is in a list of allowed values
+func containsString(haystack []string, needle string) bool {
+	for _, value := range haystack {
+		if value == needle {
+			return true
+		}
+	}
+	return false
+}
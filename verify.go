@@ -3,7 +3,10 @@ package vonage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"regexp"
 
 	"github.com/antihax/optional"
 	"github.com/vonage/vonage-go-sdk/internal/verify"
@@ -28,7 +31,10 @@ func NewVerifyClient(Auth Auth) *VerifyClient {
 	return client
 }
 
-// VerifyOpts holds all the optional arguments for the verify request function
+// VerifyOpts holds all the optional arguments for the verify request function.
+// PinExpiry (60-3600 seconds) must be a multiple of NextEventWait (60-900
+// seconds) when both are set, and PinCode is a code of your own to send
+// instead of a generated one, if your account has that feature enabled
 type VerifyOpts struct {
 	Country       string
 	SenderID      string
@@ -37,6 +43,55 @@ type VerifyOpts struct {
 	PinExpiry     int32
 	NextEventWait int32
 	WorkflowID    int32
+	PinCode       string
+}
+
+// Limits on the Verify request options
+const (
+	verifyMinPinExpiry     = 60
+	verifyMaxPinExpiry     = 3600
+	verifyMinNextEventWait = 60
+	verifyMaxNextEventWait = 900
+	verifyMaxWorkflowID    = 7
+	verifyMaxSenderIDSize  = 11
+)
+
+var verifyCountry = regexp.MustCompile(`^[A-Z]{2}$`)
+var verifyPinCode = regexp.MustCompile(`^[0-9A-Za-z]{4,10}$`)
+
+// validateVerifyOpts checks the options shared by Request and Psd2
+func validateVerifyOpts(country string, senderID string, codeLength int32, pinExpiry int32, nextEventWait int32, workflowID int32, pinCode string) error {
+	if country != "" && !verifyCountry.MatchString(country) {
+		return errors.New("Country must be a two letter country code like GB")
+	}
+	if len(senderID) > verifyMaxSenderIDSize {
+		return fmt.Errorf("SenderID can't be more than %d characters", verifyMaxSenderIDSize)
+	}
+	if codeLength != 0 && codeLength != 4 && codeLength != 6 {
+		return errors.New("CodeLength must be 4 or 6")
+	}
+	if pinExpiry != 0 && (pinExpiry < verifyMinPinExpiry || pinExpiry > verifyMaxPinExpiry) {
+		return fmt.Errorf("PinExpiry must be between %d and %d seconds", verifyMinPinExpiry, verifyMaxPinExpiry)
+	}
+	if nextEventWait != 0 && (nextEventWait < verifyMinNextEventWait || nextEventWait > verifyMaxNextEventWait) {
+		return fmt.Errorf("NextEventWait must be between %d and %d seconds", verifyMinNextEventWait, verifyMaxNextEventWait)
+	}
+	// the API quietly uses NextEventWait for PinExpiry if it isn't a multiple
+	if pinExpiry != 0 && nextEventWait != 0 && pinExpiry%nextEventWait != 0 {
+		return errors.New("PinExpiry must be a multiple of NextEventWait")
+	}
+	if workflowID != 0 && (workflowID < 1 || workflowID > verifyMaxWorkflowID) {
+		return fmt.Errorf("WorkflowID must be between 1 and %d", verifyMaxWorkflowID)
+	}
+	if pinCode != "" {
+		if !verifyPinCode.MatchString(pinCode) {
+			return errors.New("PinCode must be 4 to 10 letters or digits")
+		}
+		if codeLength != 0 && int32(len(pinCode)) != codeLength {
+			return errors.New("PinCode must be CodeLength characters long")
+		}
+	}
+	return nil
 }
 
 type VerifyRequestResponse struct {
@@ -53,12 +108,20 @@ type VerifyErrorResponse struct {
 
 // Request a number is verified for ownership
 func (client *VerifyClient) Request(number string, brand string, opts VerifyOpts) (VerifyRequestResponse, VerifyErrorResponse, error) {
+	if err := validateVerifyOpts(opts.Country, opts.SenderID, opts.CodeLength, opts.PinExpiry, opts.NextEventWait, opts.WorkflowID, opts.PinCode); err != nil {
+		return VerifyRequestResponse{}, VerifyErrorResponse{}, err
+	}
+
 	// create the client
 	verifyClient := verify.NewAPIClient(client.Config)
 
 	// set up and then parse the options
 	verifyOpts := verify.VerifyRequestOpts{}
 
+	if opts.Country != "" {
+		verifyOpts.Country = optional.NewString(opts.Country)
+	}
+
 	if opts.CodeLength != 0 {
 		verifyOpts.CodeLength = optional.NewInt32(opts.CodeLength)
 	}
@@ -75,6 +138,18 @@ func (client *VerifyClient) Request(number string, brand string, opts VerifyOpts
 		verifyOpts.SenderId = optional.NewString(opts.SenderID)
 	}
 
+	if opts.PinExpiry != 0 {
+		verifyOpts.PinExpiry = optional.NewInt32(opts.PinExpiry)
+	}
+
+	if opts.NextEventWait != 0 {
+		verifyOpts.NextEventWait = optional.NewInt32(opts.NextEventWait)
+	}
+
+	if opts.PinCode != "" {
+		verifyOpts.PinCode = optional.NewString(opts.PinCode)
+	}
+
 	ctx := context.Background()
 	result, resp, err := verifyClient.DefaultApi.VerifyRequest(ctx, "json", client.apiKey, client.apiSecret, number, brand, &verifyOpts)
 
@@ -240,24 +315,39 @@ func (client *VerifyClient) TriggerNextEvent(requestID string) (VerifyControlRes
 	return VerifyControlResponse(result), VerifyErrorResponse{}, nil
 }
 
-// VerifyPsd2Opts holds all the optional arguments for the verify psd2 function
+// VerifyPsd2Opts holds all the optional arguments for the verify psd2 function,
+// with the same limits as VerifyOpts
 type VerifyPsd2Opts struct {
 	Country       string
+	SenderID      string
 	CodeLength    int32
 	Lg            string
 	PinExpiry     int32
 	NextEventWait int32
 	WorkflowID    int32
+	PinCode       string
 }
 
 // Psd2 requests a user confirm a payment with amount and payee
 func (client *VerifyClient) Psd2(number string, payee string, amount float64, opts VerifyPsd2Opts) (VerifyRequestResponse, VerifyErrorResponse, error) {
+	if err := validateVerifyOpts(opts.Country, opts.SenderID, opts.CodeLength, opts.PinExpiry, opts.NextEventWait, opts.WorkflowID, opts.PinCode); err != nil {
+		return VerifyRequestResponse{}, VerifyErrorResponse{}, err
+	}
+
 	// create the client
 	verifyClient := verify.NewAPIClient(client.Config)
 
 	// set up and then parse the options
 	verifyOpts := verify.VerifyRequestWithPSD2Opts{}
 
+	if opts.Country != "" {
+		verifyOpts.Country = optional.NewString(opts.Country)
+	}
+
+	if opts.SenderID != "" {
+		verifyOpts.SenderId = optional.NewString(opts.SenderID)
+	}
+
 	if opts.CodeLength != 0 {
 		verifyOpts.CodeLength = optional.NewInt32(opts.CodeLength)
 	}
@@ -270,6 +360,18 @@ func (client *VerifyClient) Psd2(number string, payee string, amount float64, op
 		verifyOpts.WorkflowId = optional.NewInt32(opts.WorkflowID)
 	}
 
+	if opts.PinExpiry != 0 {
+		verifyOpts.PinExpiry = optional.NewInt32(opts.PinExpiry)
+	}
+
+	if opts.NextEventWait != 0 {
+		verifyOpts.NextEventWait = optional.NewInt32(opts.NextEventWait)
+	}
+
+	if opts.PinCode != "" {
+		verifyOpts.PinCode = optional.NewString(opts.PinCode)
+	}
+
 	ctx := context.Background()
 	result, resp, err := verifyClient.DefaultApi.VerifyRequestWithPSD2(ctx, "json", client.apiKey, client.apiSecret, number, payee, float32(amount), &verifyOpts)
 
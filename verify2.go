@@ -0,0 +1,372 @@
+package vonage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/vonage/vonage-go-sdk/internal/verify2"
+)
+
+// Verify2Client for working with version 2 of the Verify API, which sends
+// the code over a workflow of channels such as SMS, WhatsApp and email
+type Verify2Client struct {
+	Config    *verify2.Configuration
+	JWT       string
+	apiKey    string
+	apiSecret string
+}
+
+// NewVerify2Client Creates a new Verify v2 Client, supplying an Auth to work
+// with. Verify v2 accepts either a JWT or an API key and secret
+func NewVerify2Client(Auth Auth) *Verify2Client {
+	client := new(Verify2Client)
+	creds := Auth.GetCreds()
+
+	client.Config = verify2.NewConfiguration()
+	client.Config.UserAgent = GetUserAgent()
+
+	// JWTAuth has one credential, KeySecretAuth has two
+	if len(creds) == 1 {
+		client.JWT = creds[0]
+		client.Config.AddDefaultHeader("Authorization", "Bearer "+client.JWT)
+	} else {
+		client.apiKey = creds[0]
+		client.apiSecret = creds[1]
+	}
+	return client
+}
+
+// The channels a Verify v2 workflow can use
+const (
+	Verify2ChannelSms                 = "sms"
+	Verify2ChannelWhatsApp            = "whatsapp"
+	Verify2ChannelWhatsAppInteractive = "whatsapp_interactive"
+	Verify2ChannelVoice               = "voice"
+	Verify2ChannelEmail               = "email"
+	Verify2ChannelSilentAuth          = "silent_auth"
+)
+
+var verify2Channels = []string{Verify2ChannelSms, Verify2ChannelWhatsApp, Verify2ChannelWhatsAppInteractive, Verify2ChannelVoice, Verify2ChannelEmail, Verify2ChannelSilentAuth}
+
+// Limits on Verify v2 requests
+const (
+	verify2MaxWorkflows      = 3
+	verify2MaxBrandSize      = 16
+	verify2MinCodeLength     = 4
+	verify2MaxCodeLength     = 10
+	verify2MinChannelTimeout = 60
+	verify2MaxChannelTimeout = 900
+)
+
+var verify2Locale = regexp.MustCompile(`^[a-z]{2}-[a-z]{2}$`)
+var verify2Code = regexp.MustCompile(`^[0-9A-Za-z]{4,10}$`)
+
+// Verify2Workflow is one step in the workflow, tried in order until the
+// user has the code. To is a phone number, or an email address for email.
+// From is needed for WhatsApp, and AppHash, EntityId and ContentId are
+// only used with SMS
+type Verify2Workflow struct {
+	Channel     string `json:"channel"`
+	To          string `json:"to"`
+	From        string `json:"from,omitempty"`
+	AppHash     string `json:"app_hash,omitempty"`
+	EntityId    string `json:"entity_id,omitempty"`
+	ContentId   string `json:"content_id,omitempty"`
+	RedirectUrl string `json:"redirect_url,omitempty"`
+}
+
+// Verify2Sms sends the code by SMS
+func Verify2Sms(to string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelSms, To: to}
+}
+
+// Verify2WhatsApp sends the code by WhatsApp from one of your WhatsApp numbers
+func Verify2WhatsApp(to string, from string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelWhatsApp, To: to, From: from}
+}
+
+// Verify2WhatsAppInteractive asks the user to confirm with a WhatsApp button
+func Verify2WhatsAppInteractive(to string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelWhatsAppInteractive, To: to}
+}
+
+// Verify2Voice reads the code out in a phone call
+func Verify2Voice(to string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelVoice, To: to}
+}
+
+// Verify2Email sends the code by email
+func Verify2Email(to string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelEmail, To: to}
+}
+
+// Verify2SilentAuth checks the number using the mobile network, with no
+// code for the user to enter. It must be the first step in the workflow
+func Verify2SilentAuth(to string) Verify2Workflow {
+	return Verify2Workflow{Channel: Verify2ChannelSilentAuth, To: to}
+}
+
+// Verify2Opts holds the optional arguments for a Verify v2 request.
+// ChannelTimeout (60-900 seconds) is how long to wait before moving to the
+// next step of the workflow, Code is a code of your own to send and
+// DisableFraudCheck turns off the network block for this request
+type Verify2Opts struct {
+	Locale            string
+	ChannelTimeout    int32
+	CodeLength        int32
+	Code              string
+	ClientRef         string
+	DisableFraudCheck bool
+}
+
+// verify2Request is the body of a Verify v2 request
+type verify2Request struct {
+	Brand          string            `json:"brand"`
+	Workflow       []Verify2Workflow `json:"workflow"`
+	Locale         string            `json:"locale,omitempty"`
+	ChannelTimeout int32             `json:"channel_timeout,omitempty"`
+	CodeLength     int32             `json:"code_length,omitempty"`
+	Code           string            `json:"code,omitempty"`
+	ClientRef      string            `json:"client_ref,omitempty"`
+	FraudCheck     *bool             `json:"fraud_check,omitempty"`
+}
+
+// Verify2RequestResponse is returned when a verification starts. CheckUrl
+// is set when the workflow starts with silent authentication
+type Verify2RequestResponse struct {
+	RequestId string
+	CheckUrl  string
+}
+
+// Verify2CheckResponse is returned when the code is correct
+type Verify2CheckResponse struct {
+	RequestId string
+	Status    string
+}
+
+// Verify2ControlResponse is returned by Cancel and NextWorkflow
+type Verify2ControlResponse struct {
+	RequestId string
+	Command   string
+}
+
+// Verify2ErrorResponse holds the problem details returned with an error.
+// When the error body isn't problem details, Detail holds the raw body
+type Verify2ErrorResponse struct {
+	Type              string
+	Title             string
+	Detail            string
+	Instance          string
+	RequestId         string
+	InvalidParameters []verify2.ErrorResponseInvalidParameters
+}
+
+// validate checks the workflow and options before they are sent
+func (opts Verify2Opts) validate(brand string, workflow []Verify2Workflow) error {
+	if brand == "" || len(brand) > verify2MaxBrandSize {
+		return fmt.Errorf("Brand must be 1 to %d characters", verify2MaxBrandSize)
+	}
+	if len(workflow) == 0 || len(workflow) > verify2MaxWorkflows {
+		return fmt.Errorf("Workflow must have 1 to %d steps", verify2MaxWorkflows)
+	}
+
+	seen := map[string]bool{}
+	for i, step := range workflow {
+		if !containsString(verify2Channels, step.Channel) {
+			return errors.New("Unsupported workflow channel: " + step.Channel)
+		}
+		if seen[step.Channel] {
+			return errors.New("Workflow can only use each channel once: " + step.Channel)
+		}
+		seen[step.Channel] = true
+		if step.To == "" {
+			return errors.New("Workflow step " + step.Channel + " needs a To")
+		}
+		if step.Channel == Verify2ChannelWhatsApp && step.From == "" {
+			return errors.New("WhatsApp workflow steps need a From number")
+		}
+		if step.Channel == Verify2ChannelSilentAuth && i != 0 {
+			return errors.New("Silent authentication must be the first workflow step")
+		}
+	}
+
+	if opts.Locale != "" && !verify2Locale.MatchString(opts.Locale) {
+		return errors.New("Locale must be a locale like en-us")
+	}
+	if opts.ChannelTimeout != 0 && (opts.ChannelTimeout < verify2MinChannelTimeout || opts.ChannelTimeout > verify2MaxChannelTimeout) {
+		return fmt.Errorf("ChannelTimeout must be between %d and %d seconds", verify2MinChannelTimeout, verify2MaxChannelTimeout)
+	}
+	if opts.CodeLength != 0 && (opts.CodeLength < verify2MinCodeLength || opts.CodeLength > verify2MaxCodeLength) {
+		return fmt.Errorf("CodeLength must be between %d and %d", verify2MinCodeLength, verify2MaxCodeLength)
+	}
+	if opts.Code != "" {
+		if !verify2Code.MatchString(opts.Code) {
+			return errors.New("Code must be 4 to 10 letters or digits")
+		}
+		if opts.CodeLength != 0 && int32(len(opts.Code)) != opts.CodeLength {
+			return errors.New("Code must be CodeLength characters long")
+		}
+	}
+	return nil
+}
+
+// Request starts a verification, trying each step of the workflow in turn
+func (client *Verify2Client) Request(brand string, workflow []Verify2Workflow, opts Verify2Opts) (Verify2RequestResponse, Verify2ErrorResponse, error) {
+	if err := opts.validate(brand, workflow); err != nil {
+		return Verify2RequestResponse{}, Verify2ErrorResponse{}, err
+	}
+
+	body := verify2Request{
+		Brand:          brand,
+		Workflow:       workflow,
+		Locale:         opts.Locale,
+		ChannelTimeout: opts.ChannelTimeout,
+		CodeLength:     opts.CodeLength,
+		Code:           opts.Code,
+		ClientRef:      opts.ClientRef,
+	}
+	if opts.DisableFraudCheck {
+		fraudCheck := false
+		body.FraudCheck = &fraudCheck
+	}
+
+	verify2Client := verify2.NewAPIClient(client.Config)
+	requestOpts := verify2.MakeVerificationRequestOpts{VerifyRequest: optional.NewInterface(body)}
+	result, _, err := verify2Client.DefaultApi.MakeVerificationRequest(client.context(), &requestOpts)
+	if err != nil {
+		return Verify2RequestResponse{}, verify2ErrorResponse(err), err
+	}
+	return Verify2RequestResponse(result), Verify2ErrorResponse{}, nil
+}
+
+// Check the code the user entered. A wrong code gives an error with the
+// HTTP status 400, and too many wrong codes the status 410
+func (client *Verify2Client) Check(requestID string, code string) (Verify2CheckResponse, Verify2ErrorResponse, error) {
+	if requestID == "" || code == "" {
+		return Verify2CheckResponse{}, Verify2ErrorResponse{}, errors.New("Check needs a request ID and a code")
+	}
+
+	verify2Client := verify2.NewAPIClient(client.Config)
+	result, _, err := verify2Client.DefaultApi.CheckCode(client.context(), requestID, verify2.CheckRequest{Code: code})
+	if err != nil {
+		return Verify2CheckResponse{}, verify2ErrorResponse(err), err
+	}
+	return Verify2CheckResponse(result), Verify2ErrorResponse{}, nil
+}
+
+// Cancel an in-progress verification
+func (client *Verify2Client) Cancel(requestID string) (Verify2ControlResponse, Verify2ErrorResponse, error) {
+	if requestID == "" {
+		return Verify2ControlResponse{}, Verify2ErrorResponse{}, errors.New("Cancel needs a request ID")
+	}
+
+	verify2Client := verify2.NewAPIClient(client.Config)
+	_, err := verify2Client.DefaultApi.CancelRequest(client.context(), requestID)
+	if err != nil {
+		return Verify2ControlResponse{}, verify2ErrorResponse(err), err
+	}
+	return Verify2ControlResponse{RequestId: requestID, Command: "cancel"}, Verify2ErrorResponse{}, nil
+}
+
+// NextWorkflow moves on to the next step of the workflow without waiting
+// for the channel timeout
+func (client *Verify2Client) NextWorkflow(requestID string) (Verify2ControlResponse, Verify2ErrorResponse, error) {
+	if requestID == "" {
+		return Verify2ControlResponse{}, Verify2ErrorResponse{}, errors.New("NextWorkflow needs a request ID")
+	}
+
+	verify2Client := verify2.NewAPIClient(client.Config)
+	_, err := verify2Client.DefaultApi.NextWorkflow(client.context(), requestID)
+	if err != nil {
+		return Verify2ControlResponse{}, verify2ErrorResponse(err), err
+	}
+	return Verify2ControlResponse{RequestId: requestID, Command: "next_workflow"}, Verify2ErrorResponse{}, nil
+}
+
+// context carries the API key and secret when the client isn't using a JWT
+func (client *Verify2Client) context() context.Context {
+	ctx := context.Background()
+	if client.JWT == "" {
+		ctx = context.WithValue(ctx, verify2.ContextBasicAuth, verify2.BasicAuth{
+			UserName: client.apiKey,
+			Password: client.apiSecret,
+		})
+	}
+	return ctx
+}
+
+// verify2ErrorResponse reads the problem details from an API error, or
+// keeps the raw body in Detail when it isn't JSON
+func verify2ErrorResponse(err error) Verify2ErrorResponse {
+	e, ok := err.(verify2.GenericOpenAPIError)
+	if !ok {
+		return Verify2ErrorResponse{}
+	}
+
+	var errResp verify2.ErrorResponse
+	if jsonErr := json.Unmarshal(e.Body(), &errResp); jsonErr != nil {
+		return Verify2ErrorResponse{Detail: string(e.Body())}
+	}
+	return Verify2ErrorResponse(errResp)
+}
+
+// Verify2WorkflowStatus is the progress of one workflow step in a summary
+type Verify2WorkflowStatus struct {
+	Channel     string    `json:"channel"`
+	InitiatedAt time.Time `json:"initiated_at"`
+	Status      string    `json:"status"`
+}
+
+// Verify2Action is sent with silent authentication events, the user's
+// device must open CheckUrl over its mobile data connection
+type Verify2Action struct {
+	Type     string `json:"type"`
+	CheckUrl string `json:"check_url"`
+}
+
+// Verify2Event is the body of a Verify v2 status webhook. Type is "event"
+// for the progress of one channel, or "summary" for the whole request
+// once it has finished. Status is, for example, "completed", "failed",
+// "expired", "user_rejected" or "action_pending"
+type Verify2Event struct {
+	RequestId      string                  `json:"request_id"`
+	Type           string                  `json:"type"`
+	Channel        string                  `json:"channel,omitempty"`
+	Status         string                  `json:"status"`
+	TriggeredAt    time.Time               `json:"triggered_at"`
+	SubmittedAt    time.Time               `json:"submitted_at"`
+	FinalizedAt    time.Time               `json:"finalized_at"`
+	ChannelTimeout int                     `json:"channel_timeout,omitempty"`
+	Workflow       []Verify2WorkflowStatus `json:"workflow,omitempty"`
+	Price          string                  `json:"price,omitempty"`
+	ClientRef      string                  `json:"client_ref,omitempty"`
+	Action         *Verify2Action          `json:"action,omitempty"`
+}
+
+// IsSummary is true for the summary sent when the request has finished
+func (e Verify2Event) IsSummary() bool {
+	return e.Type == "summary"
+}
+
+// Completed is true when the user has been verified
+func (e Verify2Event) Completed() bool {
+	return e.Status == "completed"
+}
+
+// ParseVerify2Event reads a Verify v2 status webhook from the request body
+func ParseVerify2Event(r io.Reader) (Verify2Event, error) {
+	var event Verify2Event
+	if err := json.NewDecoder(r).Decode(&event); err != nil {
+		return Verify2Event{}, err
+	}
+	if event.RequestId == "" {
+		return Verify2Event{}, errors.New("Verify event has no request_id")
+	}
+	return event, nil
+}
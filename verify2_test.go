@@ -0,0 +1,189 @@
+package vonage
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestVerify2Request(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body, authHeader string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v2/verify",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			authHeader = req.Header.Get("Authorization")
+			resp := httpmock.NewStringResponse(202, `{"request_id": "c11236f4-00bf-4b89-84ba-88b25df97315"}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	client := NewVerify2Client(&JWTAuth{JWT: "a.b.c"})
+	workflow := []Verify2Workflow{
+		Verify2WhatsApp("447700900000", "447700900001"),
+		Verify2Sms("447700900000"),
+		Verify2Email("alice@example.com"),
+	}
+	result, _, err := client.Request("Acme", workflow, Verify2Opts{
+		Locale:            "en-gb",
+		ChannelTimeout:    120,
+		Code:              "a1b2c3",
+		ClientRef:         "signup-42",
+		DisableFraudCheck: true,
+	})
+	if err != nil {
+		t.Fatalf("Verify v2 request failed: %s", err)
+	}
+	if result.RequestId != "c11236f4-00bf-4b89-84ba-88b25df97315" {
+		t.Errorf("Unexpected request ID: %s", result.RequestId)
+	}
+	if authHeader != "Bearer a.b.c" {
+		t.Errorf("Verify v2 should send the JWT: %s", authHeader)
+	}
+
+	expected := `{"brand":"Acme","workflow":[{"channel":"whatsapp","to":"447700900000","from":"447700900001"},{"channel":"sms","to":"447700900000"},{"channel":"email","to":"alice@example.com"}],"locale":"en-gb","channel_timeout":120,"code":"a1b2c3","client_ref":"signup-42","fraud_check":false}`
+	if strings.TrimSpace(body) != expected {
+		t.Errorf("Unexpected JSON format for: Verify v2 request: %s", body)
+	}
+}
+
+func TestVerify2RequestInvalid(t *testing.T) {
+	client := NewVerify2Client(CreateAuthFromKeySecret("12345678", "456"))
+	sms := []Verify2Workflow{Verify2Sms("447700900000")}
+
+	invalid := map[string]struct {
+		brand    string
+		workflow []Verify2Workflow
+		opts     Verify2Opts
+	}{
+		"brand":           {"A brand that is too long", sms, Verify2Opts{}},
+		"empty workflow":  {"Acme", nil, Verify2Opts{}},
+		"channel":         {"Acme", []Verify2Workflow{{Channel: "pigeon", To: "447700900000"}}, Verify2Opts{}},
+		"repeated":        {"Acme", []Verify2Workflow{Verify2Sms("447700900000"), Verify2Sms("447700900001")}, Verify2Opts{}},
+		"whatsapp from":   {"Acme", []Verify2Workflow{{Channel: "whatsapp", To: "447700900000"}}, Verify2Opts{}},
+		"silent auth":     {"Acme", []Verify2Workflow{Verify2Voice("447700900000"), Verify2SilentAuth("447700900000")}, Verify2Opts{}},
+		"locale":          {"Acme", sms, Verify2Opts{Locale: "English"}},
+		"channel timeout": {"Acme", sms, Verify2Opts{ChannelTimeout: 30}},
+		"code length":     {"Acme", sms, Verify2Opts{CodeLength: 12}},
+		"code":            {"Acme", sms, Verify2Opts{Code: "12", CodeLength: 4}},
+	}
+	for name, c := range invalid {
+		if _, _, err := client.Request(c.brand, c.workflow, c.opts); err == nil {
+			t.Errorf("Verify v2 request with invalid %s should fail", name)
+		}
+	}
+}
+
+func TestVerify2Check(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var username, password string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315",
+		func(req *http.Request) (*http.Response, error) {
+			username, password, _ = req.BasicAuth()
+			data, _ := ioutil.ReadAll(req.Body)
+			resp := httpmock.NewStringResponse(400, `{"title": "Invalid Code", "type": "https://www.developer.vonage.com/api-errors/verify#invalid-code", "detail": "The code you provided does not match the expected value.", "instance": "475343c0-9239-4715-aed1-72b4a18379d1", "request_id": "c11236f4-00bf-4b89-84ba-88b25df97315"}`)
+			if strings.Contains(string(data), `"code":"1234"`) {
+				resp = httpmock.NewStringResponse(200, `{"request_id": "c11236f4-00bf-4b89-84ba-88b25df97315", "status": "completed"}`)
+			}
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	client := NewVerify2Client(CreateAuthFromKeySecret("12345678", "456"))
+	result, _, err := client.Check("c11236f4-00bf-4b89-84ba-88b25df97315", "1234")
+	if err != nil || result.Status != "completed" {
+		t.Errorf("Verify v2 check failed: %s %+v", err, result)
+	}
+	if username != "12345678" || password != "456" {
+		t.Errorf("Verify v2 should use basic auth with a key and secret")
+	}
+
+	_, errResp, err := client.Check("c11236f4-00bf-4b89-84ba-88b25df97315", "9999")
+	if err == nil || err.Error() != "400" || errResp.Title != "Invalid Code" || errResp.RequestId == "" {
+		t.Errorf("Unexpected error for a wrong code: %v %+v", err, errResp)
+	}
+}
+
+func TestVerify2CancelAndNextWorkflow(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("DELETE", "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315",
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315/next_workflow",
+		httpmock.NewStringResponder(200, ""))
+
+	client := NewVerify2Client(&JWTAuth{JWT: "a.b.c"})
+	cancelled, _, err := client.Cancel("c11236f4-00bf-4b89-84ba-88b25df97315")
+	if err != nil || cancelled.Command != "cancel" {
+		t.Errorf("Verify v2 cancel failed: %s", err)
+	}
+	next, _, err := client.NextWorkflow("c11236f4-00bf-4b89-84ba-88b25df97315")
+	if err != nil || next.Command != "next_workflow" {
+		t.Errorf("Verify v2 next workflow failed: %s", err)
+	}
+}
+
+func TestVerify2ErrorBody(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("DELETE", "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315",
+		httpmock.NewStringResponder(502, "<html>Bad Gateway</html>"))
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315/next_workflow",
+		httpmock.NewStringResponder(409, `{"title": "Conflict", "detail": "There are no more workflows", "invalid_parameters": [{"name": "request_id", "reason": "no next workflow"}]}`))
+
+	client := NewVerify2Client(&JWTAuth{JWT: "a.b.c"})
+	_, errResp, err := client.Cancel("c11236f4-00bf-4b89-84ba-88b25df97315")
+	if err == nil || errResp.Detail != "<html>Bad Gateway</html>" {
+		t.Errorf("A body that isn't JSON should be kept in Detail: %v %+v", err, errResp)
+	}
+
+	_, errResp, err = client.NextWorkflow("c11236f4-00bf-4b89-84ba-88b25df97315")
+	if err == nil || errResp.Title != "Conflict" || len(errResp.InvalidParameters) != 1 || errResp.InvalidParameters[0].Name != "request_id" {
+		t.Errorf("Unexpected error for next workflow: %v %+v", err, errResp)
+	}
+}
+
+func TestParseVerify2Event(t *testing.T) {
+	summary := `{
+		"request_id": "c11236f4-00bf-4b89-84ba-88b25df97315",
+		"submitted_at": "2022-01-01T14:00:00.000Z",
+		"status": "completed",
+		"type": "summary",
+		"channel_timeout": 300,
+		"workflow": [
+			{"channel": "sms", "initiated_at": "2022-01-01T14:00:00.000Z", "status": "expired"},
+			{"channel": "whatsapp", "initiated_at": "2022-01-01T14:05:00.000Z", "status": "completed"}
+		],
+		"price": "0.10000000",
+		"client_ref": "signup-42"
+	}`
+	event, err := ParseVerify2Event(strings.NewReader(summary))
+	if err != nil {
+		t.Fatalf("Parsing summary failed: %s", err)
+	}
+	if !event.IsSummary() || !event.Completed() || len(event.Workflow) != 2 || event.Workflow[1].InitiatedAt.Minute() != 5 {
+		t.Errorf("Unexpected summary: %+v", event)
+	}
+
+	silent := `{"request_id": "c11236f4-00bf-4b89-84ba-88b25df97315", "triggered_at": "2022-01-01T14:00:00.000Z", "type": "event", "channel": "silent_auth", "status": "action_pending", "action": {"type": "check", "check_url": "https://api.nexmo.com/v2/verify/c11236f4-00bf-4b89-84ba-88b25df97315/silent-auth/redirect"}}`
+	event, _ = ParseVerify2Event(strings.NewReader(silent))
+	if event.IsSummary() || event.Channel != "silent_auth" || event.Action == nil || event.Action.Type != "check" {
+		t.Errorf("Unexpected silent auth event: %+v", event)
+	}
+
+	if _, err := ParseVerify2Event(strings.NewReader(`{"status": "completed"}`)); err == nil {
+		t.Errorf("Event without a request ID should fail")
+	}
+}
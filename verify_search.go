@@ -0,0 +1,224 @@
+package vonage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/vonage/vonage-go-sdk/internal/verify"
+)
+
+// VerifySearchMaxRequests is the most request IDs one search can ask for
+const VerifySearchMaxRequests = 10
+
+// verifyDateFormat is how the Verify API formats its dates, always in UTC
+const verifyDateFormat = "2006-01-02 15:04:05"
+
+// VerifyCheck is one code the user submitted for a verify request
+type VerifyCheck struct {
+	Code         string
+	Status       string // VALID or INVALID
+	DateReceived time.Time
+	IpAddress    string
+}
+
+// VerifyEvent is one attempt to deliver the code, Type is the channel
+// used (sms or tts) and Id the message or call that was sent. Search
+// doesn't say when each event happened or whether it was delivered
+type VerifyEvent struct {
+	Type string
+	Id   string
+}
+
+// VerifySearchResult is a verify request returned by SearchRequests, with
+// the dates parsed and the checks and events typed
+type VerifySearchResult struct {
+	RequestId                  string
+	AccountId                  string
+	Status                     string
+	Number                     string
+	Price                      string
+	Currency                   string
+	SenderId                   string
+	DateSubmitted              time.Time
+	DateFinalized              time.Time
+	FirstEventDate             time.Time
+	LastEventDate              time.Time
+	Checks                     []VerifyCheck
+	Events                     []VerifyEvent
+	EstimatedPriceMessagesSent string
+}
+
+// VerifyTimeline summarises how a verify request played out. Search only
+// gives the first and last event dates, not the time of each event or the
+// channel the user read the code from, so LastChannel is a best guess
+type VerifyTimeline struct {
+	Submitted    time.Time
+	Events       []VerifyEvent
+	Verified     bool
+	VerifiedAt   time.Time
+	LastChannel  string        // the channel of the last event sent before the right code came in
+	TimeToVerify time.Duration // from the request being made until the right code came in
+	WrongCodes   int           // invalid codes submitted before the right one, or in total
+}
+
+// Timeline works out whether the user verified, the channel of the last
+// code sent, how long they took, and how many wrong codes they tried first
+func (r VerifySearchResult) Timeline() VerifyTimeline {
+	timeline := VerifyTimeline{Submitted: r.DateSubmitted, Events: r.Events}
+	for _, check := range r.Checks {
+		if check.Status != "VALID" {
+			timeline.WrongCodes++
+			continue
+		}
+		timeline.Verified = true
+		timeline.VerifiedAt = check.DateReceived
+		if !r.DateSubmitted.IsZero() {
+			timeline.TimeToVerify = check.DateReceived.Sub(r.DateSubmitted)
+		}
+		// no further events are sent once the code is right, so the
+		// last one is the most likely to have been read
+		if len(r.Events) > 0 {
+			timeline.LastChannel = r.Events[len(r.Events)-1].Type
+		}
+		break
+	}
+	return timeline
+}
+
+// verifySearchRecord is the JSON form of one request in a search response
+type verifySearchRecord struct {
+	RequestId      string `json:"request_id"`
+	AccountId      string `json:"account_id"`
+	Status         string `json:"status"`
+	Number         string `json:"number"`
+	Price          string `json:"price"`
+	Currency       string `json:"currency"`
+	SenderId       string `json:"sender_id"`
+	DateSubmitted  string `json:"date_submitted"`
+	DateFinalized  string `json:"date_finalized"`
+	FirstEventDate string `json:"first_event_date"`
+	LastEventDate  string `json:"last_event_date"`
+	Checks         []struct {
+		Code         string `json:"code"`
+		Status       string `json:"status"`
+		DateReceived string `json:"date_received"`
+		IpAddress    string `json:"ip_address"`
+	} `json:"checks"`
+	Events []struct {
+		Type string `json:"type"`
+		Id   string `json:"id"`
+	} `json:"events"`
+	EstimatedPriceMessagesSent string `json:"estimated_price_messages_sent"`
+}
+
+func parseVerifyDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(verifyDateFormat, value, time.UTC)
+}
+
+func (rec verifySearchRecord) result() (VerifySearchResult, error) {
+	result := VerifySearchResult{
+		RequestId:                  rec.RequestId,
+		AccountId:                  rec.AccountId,
+		Status:                     rec.Status,
+		Number:                     rec.Number,
+		Price:                      rec.Price,
+		Currency:                   rec.Currency,
+		SenderId:                   rec.SenderId,
+		EstimatedPriceMessagesSent: rec.EstimatedPriceMessagesSent,
+	}
+
+	dates := []struct {
+		value  string
+		target *time.Time
+	}{
+		{rec.DateSubmitted, &result.DateSubmitted},
+		{rec.DateFinalized, &result.DateFinalized},
+		{rec.FirstEventDate, &result.FirstEventDate},
+		{rec.LastEventDate, &result.LastEventDate},
+	}
+	for _, date := range dates {
+		parsed, err := parseVerifyDate(date.value)
+		if err != nil {
+			return VerifySearchResult{}, fmt.Errorf("Request %s has an invalid date: %s", rec.RequestId, err)
+		}
+		*date.target = parsed
+	}
+
+	for _, c := range rec.Checks {
+		received, err := parseVerifyDate(c.DateReceived)
+		if err != nil {
+			return VerifySearchResult{}, fmt.Errorf("Request %s has an invalid check date: %s", rec.RequestId, err)
+		}
+		result.Checks = append(result.Checks, VerifyCheck{Code: c.Code, Status: c.Status, DateReceived: received, IpAddress: c.IpAddress})
+	}
+	for _, e := range rec.Events {
+		result.Events = append(result.Events, VerifyEvent{Type: e.Type, Id: e.Id})
+	}
+	return result, nil
+}
+
+// SearchRequests looks up between 1 and 10 earlier requests at once,
+// returning them with typed checks and events
+func (client *VerifyClient) SearchRequests(requestIDs ...string) ([]VerifySearchResult, VerifyErrorResponse, error) {
+	if len(requestIDs) == 0 {
+		return nil, VerifyErrorResponse{}, errors.New("At least one request ID is needed")
+	}
+	if len(requestIDs) > VerifySearchMaxRequests {
+		return nil, VerifyErrorResponse{}, fmt.Errorf("Search takes at most %d request IDs", VerifySearchMaxRequests)
+	}
+
+	// create the client
+	verifyClient := verify.NewAPIClient(client.Config)
+
+	// one ID gets one request back, more get a list of them
+	verifyOpts := verify.VerifySearchOpts{}
+	if len(requestIDs) == 1 {
+		verifyOpts.RequestId = optional.NewString(requestIDs[0])
+	} else {
+		verifyOpts.RequestIds = optional.NewInterface(requestIDs)
+	}
+	ctx := context.Background()
+	_, resp, err := verifyClient.DefaultApi.VerifySearch(ctx, "json", client.apiKey, client.apiSecret, &verifyOpts)
+
+	// catch HTTP errors
+	if err != nil {
+		return nil, VerifyErrorResponse{}, err
+	}
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	var body struct {
+		verifySearchRecord
+		ErrorText            string               `json:"error_text"`
+		VerificationRequests []verifySearchRecord `json:"verification_requests"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, VerifyErrorResponse{}, err
+	}
+
+	records := body.VerificationRequests
+	if records == nil {
+		// search failed if we didn't get a request ID
+		if body.RequestId == "" {
+			return nil, VerifyErrorResponse{Status: body.Status, ErrorText: body.ErrorText}, nil
+		}
+		records = []verifySearchRecord{body.verifySearchRecord}
+	}
+
+	results := make([]VerifySearchResult, 0, len(records))
+	for _, rec := range records {
+		result, err := rec.result()
+		if err != nil {
+			return nil, VerifyErrorResponse{}, err
+		}
+		results = append(results, result)
+	}
+	return results, VerifyErrorResponse{}, nil
+}
@@ -0,0 +1,134 @@
+package vonage
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestVerifySearchRequests(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var ids []string
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/verify/search/json",
+		func(req *http.Request) (*http.Response, error) {
+			ids = req.URL.Query()["request_ids"]
+			resp := httpmock.NewStringResponse(200, `
+{
+  "verification_requests": [
+    {
+      "request_id": "abcdef0123456789abcdef0123456789",
+      "account_id": "abcdef01",
+      "number": "447700900000",
+      "sender_id": "verify",
+      "date_submitted": "2020-01-01 12:00:00",
+      "date_finalized": "2020-01-01 12:03:10",
+      "checks": [
+        {"date_received": "2020-01-01 12:02:00", "code": "1111", "status": "INVALID", "ip_address": ""},
+        {"date_received": "2020-01-01 12:03:10", "code": "1234", "status": "VALID", "ip_address": ""}
+      ],
+      "first_event_date": "2020-01-01 12:00:00",
+      "last_event_date": "2020-01-01 12:02:05",
+      "price": "0.10000000",
+      "currency": "EUR",
+      "status": "SUCCESS",
+      "events": [
+        {"type": "sms", "id": "0A00000012345678"},
+        {"type": "tts", "id": "0B00000012345678"}
+      ]
+    },
+    {
+      "request_id": "0123456789abcdef0123456789abcdef",
+      "account_id": "abcdef01",
+      "number": "447700900001",
+      "date_submitted": "2020-01-01 13:00:00",
+      "date_finalized": "2020-01-01 13:05:00",
+      "first_event_date": "2020-01-01 13:00:00",
+      "last_event_date": "2020-01-01 13:00:00",
+      "status": "EXPIRED",
+      "events": [{"type": "sms", "id": "0A00000087654321"}]
+    }
+  ]
+}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	client := NewVerifyClient(CreateAuthFromKeySecret("12345678", "456"))
+	results, _, err := client.SearchRequests("abcdef0123456789abcdef0123456789", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Verify search failed: %s", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected both request IDs in the query, got %v", ids)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if !first.DateSubmitted.Equal(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected submitted date: %s", first.DateSubmitted)
+	}
+	if len(first.Checks) != 2 || first.Checks[1].Code != "1234" || first.Checks[1].DateReceived.Minute() != 3 {
+		t.Errorf("Unexpected checks: %+v", first.Checks)
+	}
+
+	timeline := first.Timeline()
+	if !timeline.Verified || timeline.LastChannel != "tts" || timeline.WrongCodes != 1 {
+		t.Errorf("Unexpected timeline: %+v", timeline)
+	}
+	if timeline.TimeToVerify != 3*time.Minute+10*time.Second {
+		t.Errorf("Unexpected time to verify: %s", timeline.TimeToVerify)
+	}
+
+	expired := results[1].Timeline()
+	if expired.Verified || expired.LastChannel != "" || expired.TimeToVerify != 0 {
+		t.Errorf("Unexpected timeline for an expired request: %+v", expired)
+	}
+}
+
+func TestVerifySearchRequestsSingle(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/verify/search/json",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("request_id") != "abcdef0123456789abcdef0123456789" {
+				resp := httpmock.NewStringResponse(200, `{"status": "101", "error_text": "No response found"}`)
+				resp.Header.Add("Content-Type", "application/json")
+				return resp, nil
+			}
+			resp := httpmock.NewStringResponse(200, `{"request_id": "abcdef0123456789abcdef0123456789", "status": "IN PROGRESS", "date_submitted": "2020-01-01 12:00:00", "events": [{"type": "sms", "id": "0A00000012345678"}]}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	client := NewVerifyClient(CreateAuthFromKeySecret("12345678", "456"))
+	results, _, err := client.SearchRequests("abcdef0123456789abcdef0123456789")
+	if err != nil || len(results) != 1 || results[0].Status != "IN PROGRESS" || len(results[0].Events) != 1 {
+		t.Errorf("Unexpected single search result: %v %+v", err, results)
+	}
+
+	_, errResp, _ := client.SearchRequests("ffffffffffffffffffffffffffffffff")
+	message := "Error status " + errResp.Status + ": " + errResp.ErrorText
+	if message != "Error status 101: No response found" {
+		t.Errorf("Unexpected error: %s", message)
+	}
+}
+
+func TestVerifySearchRequestsLimits(t *testing.T) {
+	client := NewVerifyClient(CreateAuthFromKeySecret("12345678", "456"))
+	if _, _, err := client.SearchRequests(); err == nil {
+		t.Errorf("Search with no request IDs should fail")
+	}
+	ids := make([]string, VerifySearchMaxRequests+1)
+	if _, _, err := client.SearchRequests(ids...); err == nil {
+		t.Errorf("Search with too many request IDs should fail")
+	}
+}
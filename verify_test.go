@@ -2,6 +2,8 @@ package vonage
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
@@ -73,6 +75,34 @@ func TestVerifyRequestConcurrent(t *testing.T) {
 	}
 }
 
+// concurrentServer answers every request with a status 10 error, it is a
+// real server as httpmock lets the response body be read more than once
+func concurrentServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"request_id": "abcdef0123456789abcdef0123456789", "status": "10", "error_text": "Concurrent verifications to the same number are not allowed"}`))
+	}))
+}
+
+func TestVerifyRequestConcurrentOverHTTP(t *testing.T) {
+	server := concurrentServer()
+	defer server.Close()
+
+	client := NewVerifyClient(CreateAuthFromKeySecret("12345678", "456"))
+	client.Config.BasePath = server.URL + "/verify"
+	client.Config.HTTPClient = server.Client()
+
+	_, errResp, err := client.Request("44777000777", "VonageGoTest", VerifyOpts{})
+	if err != nil || errResp.Status != "10" || errResp.RequestId != "abcdef0123456789abcdef0123456789" || errResp.ErrorText == "" {
+		t.Errorf("Request should return the error response: %+v %v", errResp, err)
+	}
+
+	_, errResp, err = client.Psd2("44777000777", "Acme Inc", 0.99, VerifyPsd2Opts{})
+	if err != nil || errResp.Status != "10" || errResp.RequestId != "abcdef0123456789abcdef0123456789" {
+		t.Errorf("Psd2 should return the error response: %+v %v", errResp, err)
+	}
+}
+
 func TestVerifyRequestFail(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -441,3 +471,125 @@ func TestVerifyPsd2Request(t *testing.T) {
 		t.Errorf("Verify request failed")
 	}
 }
+
+// recordVerifyForm registers a successful response that keeps the form
+// params sent
+func recordVerifyForm(path string, form *url.Values) {
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/verify/"+path,
+		func(req *http.Request) (*http.Response, error) {
+			req.ParseForm()
+			*form = req.PostForm
+			resp := httpmock.NewStringResponse(200, `{"request_id": "abcdef0123456789abcdef0123456789", "status": "0"}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+}
+
+func TestVerifyRequestSendsAllOptions(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	recordVerifyForm("json", &form)
+
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewVerifyClient(auth)
+	_, _, err := client.Request("07700900000", "VonageGoTest", VerifyOpts{
+		Country:       "GB",
+		SenderID:      "Vonage",
+		CodeLength:    6,
+		Lg:            "en-gb",
+		PinExpiry:     240,
+		NextEventWait: 120,
+		WorkflowID:    6,
+		PinCode:       "123456",
+	})
+	if err != nil {
+		t.Fatalf("Verify request failed: %s", err)
+	}
+
+	expected := map[string]string{
+		"number":          "07700900000",
+		"brand":           "VonageGoTest",
+		"country":         "GB",
+		"sender_id":       "Vonage",
+		"code_length":     "6",
+		"lg":              "en-gb",
+		"pin_expiry":      "240",
+		"next_event_wait": "120",
+		"workflow_id":     "6",
+		"pin_code":        "123456",
+	}
+	for param, value := range expected {
+		if form.Get(param) != value {
+			t.Errorf("Verify request should send %s=%s, sent %q", param, value, form.Get(param))
+		}
+	}
+}
+
+func TestVerifyPsd2SendsAllOptions(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	recordVerifyForm("psd2/json", &form)
+
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewVerifyClient(auth)
+	_, _, err := client.Psd2("07700900000", "Acme", 12.5, VerifyPsd2Opts{
+		Country:       "GB",
+		SenderID:      "Acme",
+		PinExpiry:     600,
+		NextEventWait: 300,
+		PinCode:       "9876",
+	})
+	if err != nil {
+		t.Fatalf("Verify PSD2 request failed: %s", err)
+	}
+
+	expected := map[string]string{
+		"payee":           "Acme",
+		"amount":          "12.5",
+		"country":         "GB",
+		"sender_id":       "Acme",
+		"pin_expiry":      "600",
+		"next_event_wait": "300",
+		"pin_code":        "9876",
+	}
+	for param, value := range expected {
+		if form.Get(param) != value {
+			t.Errorf("Verify PSD2 request should send %s=%s, sent %q", param, value, form.Get(param))
+		}
+	}
+	if _, ok := form["workflow_id"]; ok {
+		t.Errorf("Verify PSD2 request should not send unset options")
+	}
+}
+
+func TestVerifyRequestInvalidOptions(t *testing.T) {
+	auth := CreateAuthFromKeySecret("12345678", "456")
+	client := NewVerifyClient(auth)
+
+	invalid := map[string]VerifyOpts{
+		"country":          {Country: "GBR"},
+		"sender id":        {SenderID: "ThisIsTooLongToSend"},
+		"code length":      {CodeLength: 5},
+		"short pin expiry": {PinExpiry: 30},
+		"long pin expiry":  {PinExpiry: 7200},
+		"next event wait":  {NextEventWait: 1000},
+		"not a multiple":   {PinExpiry: 200, NextEventWait: 120},
+		"workflow":         {WorkflowID: 8},
+		"pin code":         {PinCode: "12-34"},
+		"pin code length":  {PinCode: "1234", CodeLength: 6},
+	}
+	for name, opts := range invalid {
+		if _, _, err := client.Request("447700900000", "VonageGoTest", opts); err == nil {
+			t.Errorf("Verify request with invalid %s should fail", name)
+		}
+	}
+
+	if _, _, err := client.Psd2("447700900000", "Acme", 10, VerifyPsd2Opts{WorkflowID: 0, NextEventWait: 59}); err == nil {
+		t.Errorf("Verify PSD2 request with invalid next event wait should fail")
+	}
+}
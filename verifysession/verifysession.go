@@ -0,0 +1,447 @@
+// Package verifysession runs the usual verification flow on top of the
+// Verify API: start a verification, check the codes the user enters with
+// a limit on attempts, resend the code no more often than a cooldown
+// allows, and lock the number out for a while once the attempts are used
+// up. Each call returns a typed Outcome, and the state of each session is
+// kept in a Store so it can live outside the process
+package verifysession
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	vonage "github.com/vonage/vonage-go-sdk"
+)
+
+// Verify API statuses the Manager acts on
+const (
+	statusOk                  = "0"
+	statusNotFound            = "6"
+	statusConcurrent          = "10"
+	statusWrongCode           = "16"
+	statusTooManyWrongCodes   = "17"
+	statusNextEventNotAllowed = "19"
+)
+
+// Defaults used when the Manager's settings are zero
+const (
+	defaultMaxAttempts    = 3
+	defaultResendCooldown = 30 * time.Second
+	defaultLockout        = 15 * time.Minute
+)
+
+// Outcome is what happened to a session as a result of a Manager call
+type Outcome string
+
+const (
+	// OutcomeStarted is a new verification, the code has been sent
+	OutcomeStarted Outcome = "started"
+	// OutcomeResumed is a verification that was already in progress for
+	// the number, the user should enter the code they were sent
+	OutcomeResumed Outcome = "resumed"
+	// OutcomeVerified is a correct code, the session is finished
+	OutcomeVerified Outcome = "verified"
+	// OutcomeWrongCode is an incorrect code with attempts remaining
+	OutcomeWrongCode Outcome = "wrong_code"
+	// OutcomeLockedOut is a session that used up its attempts, nothing
+	// can be done until RetryAfter has passed
+	OutcomeLockedOut Outcome = "locked_out"
+	// OutcomeCooldown is a resend asked for too soon, try after RetryAfter
+	OutcomeCooldown Outcome = "cooldown"
+	// OutcomeResent is a resend, the code is sent again by the next
+	// channel in the workflow
+	OutcomeResent Outcome = "resent"
+	// OutcomeCancelled is a session that was cancelled
+	OutcomeCancelled Outcome = "cancelled"
+	// OutcomeExpired is a verification that Vonage no longer knows about,
+	// usually because the code expired. Start a new one
+	OutcomeExpired Outcome = "expired"
+	// OutcomeNotFound is a session ID that isn't in the store
+	OutcomeNotFound Outcome = "not_found"
+	// OutcomeFailed is any other error status from the Verify API, see
+	// Result.Status and Result.ErrorText
+	OutcomeFailed Outcome = "failed"
+)
+
+// Session is the state of one verification. ID is chosen by the caller,
+// such as a user ID, so the session can be found again
+type Session struct {
+	ID          string
+	Number      string
+	RequestId   string
+	Attempts    int
+	Resends     int
+	StartedAt   time.Time
+	LastSentAt  time.Time
+	LockedUntil time.Time
+}
+
+// Result is returned by each Manager call
+type Result struct {
+	Outcome Outcome
+	Session Session
+	// AttemptsRemaining is how many more codes can be checked
+	AttemptsRemaining int
+	// RetryAfter is how long to wait, for OutcomeCooldown and
+	// OutcomeLockedOut
+	RetryAfter time.Duration
+	// Status and ErrorText come from the Verify API for OutcomeFailed
+	Status    string
+	ErrorText string
+	// CancelStatus and CancelErrorText come from cancelling the request
+	// when a wrong code locks the session out. A CancelStatus other than
+	// "0" means Vonage may still accept the code until it expires
+	CancelStatus    string
+	CancelErrorText string
+}
+
+// Store keeps the sessions for a Manager. Implementations must be safe
+// to use from several goroutines
+type Store interface {
+	Get(id string) (Session, bool, error)
+	Put(session Session) error
+	Delete(id string) error
+}
+
+// MemoryStore is a Store that keeps sessions in a map
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]Session{}}
+}
+
+// Get returns the session with this ID
+func (s *MemoryStore) Get(id string) (Session, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[id]
+	return session, ok, nil
+}
+
+// Put saves the session
+func (s *MemoryStore) Put(session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// Delete removes the session
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Manager runs verification sessions. Sessions are removed from the
+// Store once verified or cancelled, and kept while locked out. Calls for
+// the same session ID run one at a time, but only within one Manager, a
+// Store shared between processes needs to lock sessions itself
+type Manager struct {
+	Client *vonage.VerifyClient
+	Brand  string
+	Opts   vonage.VerifyOpts
+	Store  Store
+	// MaxAttempts is how many codes can be checked per session, default
+	// 3. The Verify API itself allows 3 wrong codes per request
+	MaxAttempts int
+	// ResendCooldown is the shortest time between sending codes, default
+	// 30 seconds which is also the shortest time the Verify API allows
+	ResendCooldown time.Duration
+	// Lockout is how long a session is locked after too many wrong
+	// codes, default 15 minutes
+	Lockout time.Duration
+
+	now   func() time.Time
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+// sessionLock is held while a call for one session ID runs, waiting counts
+// the calls holding or waiting for it so it can be removed afterwards
+type sessionLock struct {
+	sync.Mutex
+	waiting int
+}
+
+// lockSession waits until no other call is running for the session ID,
+// so that two checks can't both read the attempts before either saves
+// them. Call the returned function when done
+func (m *Manager) lockSession(id string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = map[string]*sessionLock{}
+	}
+	lock, ok := m.locks[id]
+	if !ok {
+		lock = &sessionLock{}
+		m.locks[id] = lock
+	}
+	lock.waiting++
+	m.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		lock.waiting--
+		if lock.waiting == 0 {
+			delete(m.locks, id)
+		}
+	}
+}
+
+// New creates a Manager with a MemoryStore and the default limits
+func New(client *vonage.VerifyClient, brand string) *Manager {
+	return &Manager{Client: client, Brand: brand, Store: NewMemoryStore()}
+}
+
+func (m *Manager) clock() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+func (m *Manager) maxAttempts() int {
+	if m.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return m.MaxAttempts
+}
+
+func (m *Manager) resendCooldown() time.Duration {
+	if m.ResendCooldown <= 0 {
+		return defaultResendCooldown
+	}
+	return m.ResendCooldown
+}
+
+func (m *Manager) lockout() time.Duration {
+	if m.Lockout <= 0 {
+		return defaultLockout
+	}
+	return m.Lockout
+}
+
+func (m *Manager) result(outcome Outcome, session Session) Result {
+	remaining := m.maxAttempts() - session.Attempts
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Outcome: outcome, Session: session, AttemptsRemaining: remaining}
+}
+
+func (m *Manager) failed(session Session, errResp vonage.VerifyErrorResponse) Result {
+	result := m.result(OutcomeFailed, session)
+	result.Status = errResp.Status
+	result.ErrorText = errResp.ErrorText
+	return result
+}
+
+// lockedOut returns the lockout result if the session is still locked
+func (m *Manager) lockedOut(session Session) (Result, bool) {
+	wait := session.LockedUntil.Sub(m.clock())
+	if wait <= 0 {
+		return Result{}, false
+	}
+	result := m.result(OutcomeLockedOut, session)
+	result.RetryAfter = wait
+	return result, true
+}
+
+// lock starts the lockout for a session that has used up its attempts
+func (m *Manager) lock(session Session) (Result, error) {
+	session.Attempts = m.maxAttempts()
+	session.RequestId = ""
+	session.LockedUntil = m.clock().Add(m.lockout())
+	result := m.result(OutcomeLockedOut, session)
+	result.RetryAfter = m.lockout()
+	return result, m.Store.Put(session)
+}
+
+// Start begins verifying number for the session with this ID. When a
+// verification is already in progress for the number it is resumed
+// rather than started again
+func (m *Manager) Start(id string, number string) (Result, error) {
+	if id == "" || number == "" {
+		return Result{}, errors.New("Start needs a session ID and a number")
+	}
+	defer m.lockSession(id)()
+
+	session, found, err := m.Store.Get(id)
+	if err != nil {
+		return Result{}, err
+	}
+	if found {
+		if result, locked := m.lockedOut(session); locked {
+			return result, nil
+		}
+	}
+
+	now := m.clock()
+	fresh := Session{ID: id, Number: number, StartedAt: now, LastSentAt: now}
+	response, errResp, err := m.Client.Request(number, m.Brand, m.Opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch response.Status {
+	case statusOk:
+		fresh.RequestId = response.RequestId
+		return m.result(OutcomeStarted, fresh), m.Store.Put(fresh)
+	case statusConcurrent:
+		// the request in progress should be given, but if it isn't it can
+		// only be the one already stored for this number
+		requestID := errResp.RequestId
+		if requestID == "" && found && session.Number == number {
+			requestID = session.RequestId
+		}
+		if requestID == "" {
+			return m.failed(fresh, errResp), nil
+		}
+		// keep the attempts already made against the same request
+		if found && session.RequestId == requestID && session.Number == number {
+			return m.result(OutcomeResumed, session), nil
+		}
+		fresh.RequestId = requestID
+		return m.result(OutcomeResumed, fresh), m.Store.Put(fresh)
+	}
+	return m.failed(fresh, errResp), nil
+}
+
+// get loads a session. When done is true the session isn't found or is
+// locked out, and the result should be given back straight away
+func (m *Manager) get(id string) (session Session, result Result, done bool, err error) {
+	session, found, err := m.Store.Get(id)
+	if err != nil {
+		return Session{}, Result{}, true, err
+	}
+	if !found {
+		return Session{}, Result{Outcome: OutcomeNotFound, Session: Session{ID: id}}, true, nil
+	}
+	if result, locked := m.lockedOut(session); locked {
+		return session, result, true, nil
+	}
+	if session.RequestId == "" {
+		// the lockout has passed, the user needs to start again
+		return session, Result{Outcome: OutcomeExpired, Session: session}, true, m.Store.Delete(id)
+	}
+	return session, Result{}, false, nil
+}
+
+// Check checks the code the user entered for the session with this ID
+func (m *Manager) Check(id string, code string) (Result, error) {
+	defer m.lockSession(id)()
+	session, result, done, err := m.get(id)
+	if done {
+		return result, err
+	}
+
+	response, errResp, err := m.Client.Check(session.RequestId, code)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch response.Status {
+	case statusOk:
+		session.Attempts++
+		return m.result(OutcomeVerified, session), m.Store.Delete(id)
+	case statusWrongCode:
+		session.Attempts++
+		if session.Attempts >= m.maxAttempts() {
+			// stop the request so the code can't be used any more
+			cancel, cancelErrResp, cancelErr := m.Client.Cancel(session.RequestId)
+			result, err := m.lock(session)
+			switch {
+			case cancelErr != nil:
+				result.CancelErrorText = cancelErr.Error()
+			case cancelErrResp.Status != "":
+				result.CancelStatus = cancelErrResp.Status
+				result.CancelErrorText = cancelErrResp.ErrorText
+			default:
+				result.CancelStatus = cancel.Status
+			}
+			return result, err
+		}
+		return m.result(OutcomeWrongCode, session), m.Store.Put(session)
+	case statusTooManyWrongCodes:
+		return m.lock(session)
+	case statusNotFound:
+		return m.result(OutcomeExpired, session), m.Store.Delete(id)
+	}
+	return m.failed(session, errResp), nil
+}
+
+// Resend sends the code again using the next channel in the workflow,
+// as long as the cooldown since the code was last sent has passed
+func (m *Manager) Resend(id string) (Result, error) {
+	defer m.lockSession(id)()
+	session, result, done, err := m.get(id)
+	if done {
+		return result, err
+	}
+
+	if wait := session.LastSentAt.Add(m.resendCooldown()).Sub(m.clock()); wait > 0 {
+		result = m.result(OutcomeCooldown, session)
+		result.RetryAfter = wait
+		return result, nil
+	}
+
+	response, errResp, err := m.Client.TriggerNextEvent(session.RequestId)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch errResp.Status {
+	case "":
+		if response.Status != statusOk {
+			return m.failed(session, vonage.VerifyErrorResponse{Status: response.Status}), nil
+		}
+		session.Resends++
+		session.LastSentAt = m.clock()
+		return m.result(OutcomeResent, session), m.Store.Put(session)
+	case statusNextEventNotAllowed:
+		result = m.result(OutcomeCooldown, session)
+		result.RetryAfter = m.resendCooldown()
+		return result, nil
+	case statusNotFound:
+		return m.result(OutcomeExpired, session), m.Store.Delete(id)
+	}
+	return m.failed(session, errResp), nil
+}
+
+// Cancel stops the verification for the session with this ID. Vonage
+// only allows cancelling between 30 seconds after the request and the
+// second event being sent, otherwise the Result is OutcomeFailed and
+// the session is kept
+func (m *Manager) Cancel(id string) (Result, error) {
+	defer m.lockSession(id)()
+	session, result, done, err := m.get(id)
+	if done {
+		return result, err
+	}
+
+	response, errResp, err := m.Client.Cancel(session.RequestId)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch errResp.Status {
+	case "":
+		if response.Status != statusOk {
+			return m.failed(session, vonage.VerifyErrorResponse{Status: response.Status}), nil
+		}
+		return m.result(OutcomeCancelled, session), m.Store.Delete(id)
+	case statusNotFound:
+		return m.result(OutcomeExpired, session), m.Store.Delete(id)
+	}
+	return m.failed(session, errResp), nil
+}
@@ -0,0 +1,345 @@
+package verifysession
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	vonage "github.com/vonage/vonage-go-sdk"
+)
+
+// respond registers a Verify endpoint that answers with each body in
+// turn, repeating the last one, and counts the calls made
+func respond(path string, bodies ...string) *int {
+	calls := 0
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/verify/"+path,
+		func(req *http.Request) (*http.Response, error) {
+			body := bodies[len(bodies)-1]
+			if calls < len(bodies) {
+				body = bodies[calls]
+			}
+			calls++
+			resp := httpmock.NewStringResponse(200, body)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+	return &calls
+}
+
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func testManager() (*Manager, *testClock) {
+	clock := &testClock{t: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	m := New(vonage.NewVerifyClient(vonage.CreateAuthFromKeySecret("12345678", "456")), "Acme")
+	m.now = clock.now
+	return m, clock
+}
+
+func TestStartAndCheck(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	respond("check/json",
+		`{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`,
+		`{"request_id": "req-1", "event_id": "ev-1", "status": "0", "price": "0.10000000", "currency": "EUR"}`,
+	)
+
+	m, _ := testManager()
+	result, err := m.Start("user-1", "447700900000")
+	if err != nil || result.Outcome != OutcomeStarted || result.Session.RequestId != "req-1" || result.AttemptsRemaining != 3 {
+		t.Fatalf("Unexpected start: %v %+v", err, result)
+	}
+
+	result, _ = m.Check("user-1", "0000")
+	if result.Outcome != OutcomeWrongCode || result.AttemptsRemaining != 2 {
+		t.Errorf("Unexpected wrong code result: %+v", result)
+	}
+
+	result, _ = m.Check("user-1", "1234")
+	if result.Outcome != OutcomeVerified {
+		t.Errorf("Unexpected verified result: %+v", result)
+	}
+	if _, found, _ := m.Store.Get("user-1"); found {
+		t.Errorf("Verified session should be removed from the store")
+	}
+
+	result, _ = m.Check("user-1", "1234")
+	if result.Outcome != OutcomeNotFound {
+		t.Errorf("Checking a finished session should not find it: %+v", result)
+	}
+}
+
+func TestStartResumesConcurrentVerification(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json",
+		`{"request_id": "req-1", "status": "0"}`,
+		`{"request_id": "req-1", "status": "10", "error_text": "Concurrent verifications to the same number are not allowed"}`,
+	)
+	respond("check/json", `{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`)
+
+	m, _ := testManager()
+	m.Start("user-1", "447700900000")
+	m.Check("user-1", "0000")
+
+	result, err := m.Start("user-1", "447700900000")
+	if err != nil || result.Outcome != OutcomeResumed || result.Session.RequestId != "req-1" {
+		t.Fatalf("Unexpected resume: %v %+v", err, result)
+	}
+	if result.AttemptsRemaining != 2 {
+		t.Errorf("Resuming should keep the attempts already made: %+v", result)
+	}
+
+	// a session the store doesn't know about picks up the existing request
+	result, _ = m.Start("user-2", "447700900000")
+	if result.Outcome != OutcomeResumed || result.Session.RequestId != "req-1" || result.AttemptsRemaining != 3 {
+		t.Errorf("Unexpected resume for a new session: %+v", result)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := respond("json",
+		`{"request_id": "req-1", "status": "0"}`,
+		`{"request_id": "req-2", "status": "0"}`,
+	)
+	respond("check/json", `{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`)
+	cancels := respond("control/json", `{"status": "0", "command": "cancel"}`)
+
+	m, clock := testManager()
+	m.MaxAttempts = 2
+	m.Lockout = 10 * time.Minute
+	m.Start("user-1", "447700900000")
+	m.Check("user-1", "0000")
+
+	result, _ := m.Check("user-1", "1111")
+	if result.Outcome != OutcomeLockedOut || result.RetryAfter != 10*time.Minute || result.AttemptsRemaining != 0 {
+		t.Errorf("Unexpected lockout: %+v", result)
+	}
+	if *cancels != 1 || result.CancelStatus != "0" {
+		t.Errorf("Locking out should cancel the request, cancelled %d times: %+v", *cancels, result)
+	}
+
+	clock.t = clock.t.Add(4 * time.Minute)
+	result, _ = m.Start("user-1", "447700900000")
+	if result.Outcome != OutcomeLockedOut || result.RetryAfter != 6*time.Minute {
+		t.Errorf("Start should be locked out: %+v", result)
+	}
+	if *requests != 1 {
+		t.Errorf("No request should be made while locked out")
+	}
+
+	clock.t = clock.t.Add(7 * time.Minute)
+	result, _ = m.Check("user-1", "1234")
+	if result.Outcome != OutcomeExpired {
+		t.Errorf("Check after the lockout should need a new start: %+v", result)
+	}
+	result, _ = m.Start("user-1", "447700900000")
+	if result.Outcome != OutcomeStarted || result.Session.RequestId != "req-2" || result.AttemptsRemaining != 2 {
+		t.Errorf("Unexpected start after the lockout: %+v", result)
+	}
+}
+
+func TestLockoutCancelFailed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	respond("check/json", `{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`)
+	respond("control/json", `{"status": "19", "error_text": "Verification request  ['req-1'] can't be cancelled within the first 30 seconds."}`)
+
+	m, _ := testManager()
+	m.MaxAttempts = 1
+	m.Start("user-1", "447700900000")
+	result, _ := m.Check("user-1", "0000")
+	if result.Outcome != OutcomeLockedOut || result.CancelStatus != "19" || result.CancelErrorText == "" {
+		t.Errorf("Lockout should report the cancel failing: %+v", result)
+	}
+}
+
+func TestConcurrentChecks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	cancels := respond("control/json", `{"status": "0", "command": "cancel"}`)
+
+	// slow checks give the other goroutines time to read the session
+	var mu sync.Mutex
+	checks := 0
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/verify/check/json",
+		func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			checks++
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			resp := httpmock.NewStringResponse(200, `{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	m, _ := testManager()
+	m.Start("user-1", "447700900000")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Check("user-1", "0000")
+		}()
+	}
+	wg.Wait()
+
+	if checks != defaultMaxAttempts || *cancels != 1 {
+		t.Errorf("Checks at the same time should stop at the limit: %d checks, %d cancels", checks, *cancels)
+	}
+}
+
+func TestTooManyWrongCodesFromApi(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	respond("check/json", `{"request_id": "req-1", "status": "17", "error_text": "The wrong code was provided too many times"}`)
+
+	m, _ := testManager()
+	m.Start("user-1", "447700900000")
+	result, _ := m.Check("user-1", "0000")
+	if result.Outcome != OutcomeLockedOut || result.RetryAfter != defaultLockout {
+		t.Errorf("Unexpected result for status 17: %+v", result)
+	}
+}
+
+func TestStartConcurrentWithoutRequestId(t *testing.T) {
+	// a real server, so the error response is read as it would be from
+	// Vonage rather than the way httpmock hands it over
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/verify/json":
+			requests++
+			if requests == 1 {
+				w.Write([]byte(`{"request_id": "req-1", "status": "0"}`))
+				return
+			}
+			w.Write([]byte(`{"status": "10", "error_text": "Concurrent verifications to the same number are not allowed"}`))
+		case "/verify/check/json":
+			w.Write([]byte(`{"request_id": "req-1", "status": "16", "error_text": "The code provided does not match the expected value"}`))
+		}
+	}))
+	defer server.Close()
+
+	m, _ := testManager()
+	m.Client.Config.BasePath = server.URL + "/verify"
+	m.Client.Config.HTTPClient = server.Client()
+
+	m.Start("user-1", "447700900000")
+	m.Check("user-1", "0000")
+
+	result, err := m.Start("user-1", "447700900000")
+	if err != nil || result.Outcome != OutcomeResumed || result.Session.RequestId != "req-1" || result.AttemptsRemaining != 2 {
+		t.Errorf("Start should resume the stored request: %v %+v", err, result)
+	}
+	result, _ = m.Check("user-1", "1111")
+	if result.Outcome != OutcomeWrongCode {
+		t.Errorf("Resumed session should still be checked against its request: %+v", result)
+	}
+
+	// with nothing stored there is no request to resume
+	result, _ = m.Start("user-2", "447700900000")
+	if result.Outcome != OutcomeFailed || result.Status != "10" {
+		t.Errorf("Start without a request ID to resume should fail: %+v", result)
+	}
+	if _, found, _ := m.Store.Get("user-2"); found {
+		t.Errorf("Failed session should not be stored")
+	}
+}
+
+func TestResendCooldown(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	triggers := respond("control/json",
+		`{"status": "0", "command": "trigger_next_event"}`,
+		`{"status": "19", "error_text": "No more events are left to execute for the request"}`,
+	)
+
+	m, clock := testManager()
+	m.Start("user-1", "447700900000")
+
+	clock.t = clock.t.Add(10 * time.Second)
+	result, _ := m.Resend("user-1")
+	if result.Outcome != OutcomeCooldown || result.RetryAfter != 20*time.Second || *triggers != 0 {
+		t.Errorf("Unexpected early resend: %+v", result)
+	}
+
+	clock.t = clock.t.Add(25 * time.Second)
+	result, _ = m.Resend("user-1")
+	if result.Outcome != OutcomeResent || result.Session.Resends != 1 || !result.Session.LastSentAt.Equal(clock.t) {
+		t.Errorf("Unexpected resend: %+v", result)
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+	result, _ = m.Resend("user-1")
+	if result.Outcome != OutcomeCooldown || result.RetryAfter != defaultResendCooldown {
+		t.Errorf("Status 19 should be a cooldown: %+v", result)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"request_id": "req-1", "status": "0"}`)
+	respond("control/json", `{"status": "0", "command": "cancel"}`)
+
+	m, _ := testManager()
+	m.Start("user-1", "447700900000")
+	result, err := m.Cancel("user-1")
+	if err != nil || result.Outcome != OutcomeCancelled {
+		t.Errorf("Unexpected cancel: %v %+v", err, result)
+	}
+	if _, found, _ := m.Store.Get("user-1"); found {
+		t.Errorf("Cancelled session should be removed from the store")
+	}
+}
+
+func TestStartFailed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	respond("json", `{"status": "3", "error_text": "Invalid value for param: number"}`)
+
+	m, _ := testManager()
+	result, err := m.Start("user-1", "not a number")
+	if err != nil || result.Outcome != OutcomeFailed || result.Status != "3" || result.ErrorText == "" {
+		t.Errorf("Unexpected failed start: %v %+v", err, result)
+	}
+	if _, found, _ := m.Store.Get("user-1"); found {
+		t.Errorf("Failed session should not be stored")
+	}
+	if _, err := m.Start("", "447700900000"); err == nil {
+		t.Errorf("Start without a session ID should fail")
+	}
+}
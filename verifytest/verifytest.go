@@ -0,0 +1,521 @@
+// Package verifytest runs a fake Verify API in process for tests. The
+// Server answers the request, PSD2, check, search and control endpoints
+// with the same statuses as Vonage: 10 for a second verification to the
+// same number, 16 for a wrong code, 17 once the wrong code has been given
+// three times, and so on. Tests read the code that was "sent" with Code
+// and point a VerifyClient at the server with Configure
+package verifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	vonage "github.com/vonage/vonage-go-sdk"
+)
+
+// Statuses of a verification, as returned by search
+const (
+	StatusInProgress = "IN PROGRESS"
+	StatusSuccess    = "SUCCESS"
+	StatusFailed     = "FAILED"
+	StatusExpired    = "EXPIRED"
+	StatusCancelled  = "CANCELLED"
+)
+
+// MaxWrongCodes is how many wrong codes end a verification
+const MaxWrongCodes = 3
+
+// verifyDateFormat is how the Verify API formats its dates
+const verifyDateFormat = "2006-01-02 15:04:05"
+
+// workflows lists the channel of each event in the Verify workflows
+var workflows = map[int][]string{
+	1: {"sms", "tts", "tts"},
+	2: {"sms", "sms", "tts"},
+	3: {"tts", "tts"},
+	4: {"sms", "sms"},
+	5: {"sms", "tts"},
+	6: {"sms"},
+	7: {"tts"},
+}
+
+var (
+	numberFormat = regexp.MustCompile(`^[0-9]{6,15}$`)
+	codeFormat   = regexp.MustCompile(`^[0-9A-Za-z]{4,10}$`)
+)
+
+// Check is one code submitted for a verification
+type Check struct {
+	Code         string
+	Status       string // VALID or INVALID
+	DateReceived time.Time
+}
+
+// Event is one attempt to send the code
+type Event struct {
+	Type string // sms or tts
+	Id   string
+	Sent time.Time
+}
+
+// Verification is the state the fake server keeps for each request
+type Verification struct {
+	RequestId     string
+	Number        string
+	Brand         string
+	SenderId      string
+	Payee         string
+	Amount        string
+	Code          string
+	Status        string
+	Workflow      int
+	PinExpiry     time.Duration
+	NextEventWait time.Duration
+	Submitted     time.Time
+	Finalized     time.Time
+	Checks        []Check
+	Events        []Event
+	WrongCodes    int
+}
+
+// Server is a fake Verify API. Create it with NewServer and Close it at
+// the end of the test
+type Server struct {
+	*httptest.Server
+	// ApiKey and ApiSecret are the credentials to accept, any are
+	// accepted while ApiKey is empty
+	ApiKey    string
+	ApiSecret string
+	// Now is the clock used for expiry and timestamps, defaults to time.Now
+	Now func() time.Time
+	// GenerateCode makes the code for a request without a pin_code,
+	// defaults to random digits
+	GenerateCode func(length int) string
+
+	mu            sync.Mutex
+	verifications map[string]*Verification
+	order         []string
+	ids           int
+}
+
+// NewServer starts a fake Verify API
+func NewServer() *Server {
+	s := &Server{verifications: map[string]*Verification{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/verify/json", s.handleRequest)
+	mux.HandleFunc("/verify/psd2/json", s.handlePsd2)
+	mux.HandleFunc("/verify/check/json", s.handleCheck)
+	mux.HandleFunc("/verify/search/json", s.handleSearch)
+	mux.HandleFunc("/verify/control/json", s.handleControl)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BasePath is the base path for a verify.Configuration using this server
+func (s *Server) BasePath() string {
+	return s.URL + "/verify"
+}
+
+// Configure points the client at this server
+func (s *Server) Configure(client *vonage.VerifyClient) *vonage.VerifyClient {
+	client.Config.BasePath = s.BasePath()
+	client.Config.HTTPClient = s.Client()
+	return client
+}
+
+// Code returns the code sent for a request
+func (s *Server) Code(requestID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.verifications[requestID]
+	if !ok {
+		return "", false
+	}
+	return v.Code, true
+}
+
+// LatestCode returns the code of the most recent request to number
+func (s *Server) LatestCode(number string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.order) - 1; i >= 0; i-- {
+		if v := s.verifications[s.order[i]]; v.Number == number {
+			return v.Code, true
+		}
+	}
+	return "", false
+}
+
+// Verification returns a copy of the state of a request
+func (s *Server) Verification(requestID string) (Verification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.verifications[requestID]
+	if !ok {
+		return Verification{}, false
+	}
+	s.advance(v)
+	copied := *v
+	copied.Checks = append([]Check(nil), v.Checks...)
+	copied.Events = append([]Event(nil), v.Events...)
+	return copied, true
+}
+
+func (s *Server) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+func (s *Server) newId() string {
+	s.ids++
+	return fmt.Sprintf("%032x", s.ids)
+}
+
+func randomCode(length int) string {
+	digits := make([]byte, length)
+	for i := range digits {
+		digits[i] = byte('0' + rand.Intn(10))
+	}
+	return string(digits)
+}
+
+// advance brings the verification up to date with the clock: sending
+// the next events of the workflow as each next_event_wait passes, and
+// expiring it once the pin expiry has passed
+func (s *Server) advance(v *Verification) {
+	if v.Status != StatusInProgress {
+		return
+	}
+	now := s.now()
+	expiry := v.Submitted.Add(v.PinExpiry)
+	steps := workflows[v.Workflow]
+	for len(v.Events) < len(steps) {
+		next := v.Events[len(v.Events)-1].Sent.Add(v.NextEventWait)
+		if next.After(now) || !next.Before(expiry) {
+			break
+		}
+		v.Events = append(v.Events, Event{Type: steps[len(v.Events)], Id: s.newId(), Sent: next})
+	}
+	if !now.Before(expiry) {
+		v.Status = StatusExpired
+		v.Finalized = expiry
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, requestID string, status string, errorText string) {
+	body := map[string]string{"status": status, "error_text": errorText}
+	if requestID != "" {
+		body["request_id"] = requestID
+	}
+	writeJSON(w, body)
+}
+
+// authorised parses the form and checks the credentials, writing the
+// error response when they are missing or wrong
+func (s *Server) authorised(w http.ResponseWriter, r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "", "3", "Invalid request: "+err.Error())
+		return false
+	}
+	key, secret := r.Form.Get("api_key"), r.Form.Get("api_secret")
+	if key == "" || secret == "" {
+		writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `api_key` or `api_secret`")
+		return false
+	}
+	if s.ApiKey != "" && (key != s.ApiKey || secret != s.ApiSecret) {
+		writeError(w, "", "4", "Bad Credentials")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(w, r) {
+		return
+	}
+	if r.Form.Get("brand") == "" {
+		writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `brand`")
+		return
+	}
+	s.start(w, r, Verification{Brand: r.Form.Get("brand")})
+}
+
+func (s *Server) handlePsd2(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(w, r) {
+		return
+	}
+	for _, param := range []string{"payee", "amount"} {
+		if r.Form.Get(param) == "" {
+			writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `"+param+"`")
+			return
+		}
+	}
+	if _, err := strconv.ParseFloat(r.Form.Get("amount"), 64); err != nil {
+		writeError(w, "", "3", "Invalid value for param: amount")
+		return
+	}
+	s.start(w, r, Verification{Payee: r.Form.Get("payee"), Amount: r.Form.Get("amount")})
+}
+
+// start creates a verification from the request and PSD2 parameters the
+// two endpoints share, and sends its first event
+func (s *Server) start(w http.ResponseWriter, r *http.Request, v Verification) {
+	v.Number = r.Form.Get("number")
+	v.SenderId = r.Form.Get("sender_id")
+	if v.SenderId == "" {
+		v.SenderId = "verify"
+	}
+	if v.Number == "" {
+		writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `number`")
+		return
+	}
+	if !numberFormat.MatchString(v.Number) {
+		writeError(w, "", "3", "Invalid value for param: number")
+		return
+	}
+
+	codeLength, ok := intParam(r, "code_length", 4)
+	if !ok || (codeLength != 4 && codeLength != 6) {
+		writeError(w, "", "3", "Invalid value for param: code_length")
+		return
+	}
+	if v.Workflow, ok = intParam(r, "workflow_id", 1); !ok || workflows[v.Workflow] == nil {
+		writeError(w, "", "3", "Invalid value for param: workflow_id")
+		return
+	}
+	pinExpiry, ok := intParam(r, "pin_expiry", 300)
+	if !ok || pinExpiry < 60 || pinExpiry > 3600 {
+		writeError(w, "", "3", "Invalid value for param: pin_expiry")
+		return
+	}
+	nextEventWait, ok := intParam(r, "next_event_wait", 300)
+	if !ok || nextEventWait < 60 || nextEventWait > 900 {
+		writeError(w, "", "3", "Invalid value for param: next_event_wait")
+		return
+	}
+	v.PinExpiry = time.Duration(pinExpiry) * time.Second
+	v.NextEventWait = time.Duration(nextEventWait) * time.Second
+
+	v.Code = r.Form.Get("pin_code")
+	if v.Code != "" && !codeFormat.MatchString(v.Code) {
+		writeError(w, "", "3", "Invalid value for param: pin_code")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.order {
+		existing := s.verifications[id]
+		s.advance(existing)
+		if existing.Number == v.Number && existing.Status == StatusInProgress {
+			writeError(w, existing.RequestId, "10", "Concurrent verifications to the same number are not allowed")
+			return
+		}
+	}
+
+	if v.Code == "" {
+		generate := s.GenerateCode
+		if generate == nil {
+			generate = randomCode
+		}
+		v.Code = generate(codeLength)
+	}
+	v.RequestId = s.newId()
+	v.Status = StatusInProgress
+	v.Submitted = s.now()
+	v.Events = []Event{{Type: workflows[v.Workflow][0], Id: s.newId(), Sent: v.Submitted}}
+	s.verifications[v.RequestId] = &v
+	s.order = append(s.order, v.RequestId)
+
+	writeJSON(w, map[string]string{"request_id": v.RequestId, "status": "0"})
+}
+
+func intParam(r *http.Request, name string, fallback int) (int, bool) {
+	value := r.Form.Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+// active finds an in-progress verification, writing status 6 when there
+// isn't one with this ID
+func (s *Server) active(w http.ResponseWriter, requestID string) (*Verification, bool) {
+	v, ok := s.verifications[requestID]
+	if ok {
+		s.advance(v)
+	}
+	if !ok || v.Status != StatusInProgress {
+		writeError(w, requestID, "6", fmt.Sprintf("The Vonage platform was unable to process this message for the following reason: Request '%s' was not found or it has been verified already.", requestID))
+		return nil, false
+	}
+	return v, true
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(w, r) {
+		return
+	}
+	requestID, code := r.Form.Get("request_id"), r.Form.Get("code")
+	if requestID == "" || code == "" {
+		writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `request_id` or `code`")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.active(w, requestID)
+	if !ok {
+		return
+	}
+
+	now := s.now()
+	if code != v.Code {
+		v.Checks = append(v.Checks, Check{Code: code, Status: "INVALID", DateReceived: now})
+		v.WrongCodes++
+		if v.WrongCodes >= MaxWrongCodes {
+			v.Status = StatusFailed
+			v.Finalized = now
+			writeError(w, requestID, "17", "The wrong code was provided too many times")
+			return
+		}
+		writeError(w, requestID, "16", "The code provided does not match the expected value")
+		return
+	}
+
+	v.Checks = append(v.Checks, Check{Code: code, Status: "VALID", DateReceived: now})
+	v.Status = StatusSuccess
+	v.Finalized = now
+	writeJSON(w, map[string]string{
+		"request_id": requestID,
+		"event_id":   v.Events[len(v.Events)-1].Id,
+		"status":     "0",
+		"price":      v.price(),
+		"currency":   "EUR",
+	})
+}
+
+func (v *Verification) price() string {
+	return fmt.Sprintf("%.8f", 0.05*float64(len(v.Events)))
+}
+
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(w, r) {
+		return
+	}
+	requestID, cmd := r.Form.Get("request_id"), r.Form.Get("cmd")
+	if cmd != "cancel" && cmd != "trigger_next_event" {
+		writeError(w, requestID, "3", "Invalid value for param: cmd")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.active(w, requestID)
+	if !ok {
+		return
+	}
+
+	now := s.now()
+	steps := workflows[v.Workflow]
+	switch cmd {
+	case "cancel":
+		// Vonage allows cancelling from 30 seconds after the request
+		// until the second event is sent
+		if now.Sub(v.Submitted) < 30*time.Second || len(v.Events) > 1 {
+			writeError(w, requestID, "19", fmt.Sprintf("Verification request ['%s'] can't be cancelled now. Too many attempts to re-deliver have already been made.", requestID))
+			return
+		}
+		v.Status = StatusCancelled
+		v.Finalized = now
+	case "trigger_next_event":
+		if len(v.Events) >= len(steps) {
+			writeError(w, requestID, "19", "No more events are left to execute for the request")
+			return
+		}
+		v.Events = append(v.Events, Event{Type: steps[len(v.Events)], Id: s.newId(), Sent: now})
+	}
+	writeJSON(w, map[string]string{"status": "0", "command": cmd})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(w, r) {
+		return
+	}
+	ids := r.Form["request_ids"]
+	if id := r.Form.Get("request_id"); id != "" {
+		ids = []string{id}
+	}
+	if len(ids) == 0 {
+		writeError(w, "", "2", "Your request is incomplete and missing the mandatory parameter `request_id`")
+		return
+	}
+	if len(ids) > vonage.VerifySearchMaxRequests {
+		writeError(w, "", "3", "Invalid value for param: request_ids")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []map[string]interface{}
+	for _, id := range ids {
+		if v, ok := s.verifications[id]; ok {
+			s.advance(v)
+			found = append(found, v.searchResult())
+		}
+	}
+	if len(found) == 0 {
+		writeError(w, "", "101", "No response found")
+		return
+	}
+	if r.Form.Get("request_id") != "" {
+		writeJSON(w, found[0])
+		return
+	}
+	writeJSON(w, map[string]interface{}{"verification_requests": found})
+}
+
+func (v *Verification) searchResult() map[string]interface{} {
+	checks := make([]map[string]string, len(v.Checks))
+	for i, c := range v.Checks {
+		checks[i] = map[string]string{"date_received": c.DateReceived.UTC().Format(verifyDateFormat), "code": c.Code, "status": c.Status, "ip_address": ""}
+	}
+	events := make([]map[string]string, len(v.Events))
+	for i, e := range v.Events {
+		events[i] = map[string]string{"type": e.Type, "id": e.Id}
+	}
+
+	result := map[string]interface{}{
+		"request_id":       v.RequestId,
+		"account_id":       "abcdef01",
+		"number":           v.Number,
+		"sender_id":        v.SenderId,
+		"date_submitted":   v.Submitted.UTC().Format(verifyDateFormat),
+		"first_event_date": v.Events[0].Sent.UTC().Format(verifyDateFormat),
+		"last_event_date":  v.Events[len(v.Events)-1].Sent.UTC().Format(verifyDateFormat),
+		"price":            v.price(),
+		"currency":         "EUR",
+		"status":           v.Status,
+		"checks":           checks,
+		"events":           events,
+	}
+	if !v.Finalized.IsZero() {
+		result["date_finalized"] = v.Finalized.UTC().Format(verifyDateFormat)
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package verifytest
+
+import (
+	"testing"
+	"time"
+
+	vonage "github.com/vonage/vonage-go-sdk"
+	"github.com/vonage/vonage-go-sdk/verifysession"
+)
+
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func testServer() (*Server, *vonage.VerifyClient, *testClock) {
+	clock := &testClock{t: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	server := NewServer()
+	server.Now = clock.now
+	client := server.Configure(vonage.NewVerifyClient(vonage.CreateAuthFromKeySecret("12345678", "456")))
+	return server, client, clock
+}
+
+func TestRequestAndCheck(t *testing.T) {
+	server, client, clock := testServer()
+	defer server.Close()
+
+	response, _, err := client.Request("447700900000", "Acme", vonage.VerifyOpts{})
+	if err != nil || response.Status != "0" || response.RequestId == "" {
+		t.Fatalf("Unexpected request: %v %+v", err, response)
+	}
+	code, ok := server.Code(response.RequestId)
+	if !ok || len(code) != 4 {
+		t.Fatalf("Expected a 4 digit code, got %q", code)
+	}
+	if latest, _ := server.LatestCode("447700900000"); latest != code {
+		t.Errorf("Latest code for the number should be %q, got %q", code, latest)
+	}
+
+	check, errResp, _ := client.Check(response.RequestId, "wrong")
+	if check.Status != "16" || errResp.ErrorText == "" {
+		t.Errorf("Wrong code should give status 16: %+v", errResp)
+	}
+
+	clock.t = clock.t.Add(40 * time.Second)
+	check, _, err = client.Check(response.RequestId, code)
+	if err != nil || check.Status != "0" || check.EventId == "" || check.Currency != "EUR" {
+		t.Errorf("Unexpected check: %v %+v", err, check)
+	}
+
+	check, _, _ = client.Check(response.RequestId, code)
+	if check.Status != "6" {
+		t.Errorf("Checking a verified request should give status 6, got %s", check.Status)
+	}
+
+	results, _, err := client.SearchRequests(response.RequestId)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Unexpected search: %v %+v", err, results)
+	}
+	timeline := results[0].Timeline()
+	if results[0].Status != StatusSuccess || !timeline.Verified || timeline.WrongCodes != 1 || timeline.LastChannel != "sms" || timeline.TimeToVerify != 40*time.Second {
+		t.Errorf("Unexpected search result: %+v %+v", results[0], timeline)
+	}
+}
+
+func TestConcurrentVerification(t *testing.T) {
+	server, client, _ := testServer()
+	defer server.Close()
+
+	first, _, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{})
+	second, errResp, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{})
+	if second.Status != "10" || errResp.RequestId != first.RequestId {
+		t.Errorf("Second request should give status 10 with the first request ID: %+v", errResp)
+	}
+
+	other, _, _ := client.Request("447700900001", "Acme", vonage.VerifyOpts{})
+	if other.Status != "0" {
+		t.Errorf("Request to another number should be allowed: %+v", other)
+	}
+}
+
+func TestTooManyWrongCodes(t *testing.T) {
+	server, client, _ := testServer()
+	defer server.Close()
+
+	response, _, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{PinCode: "abc123", CodeLength: 6})
+	statuses := []string{}
+	for i := 0; i < 4; i++ {
+		check, _, _ := client.Check(response.RequestId, "000000")
+		statuses = append(statuses, check.Status)
+	}
+	if statuses[0] != "16" || statuses[1] != "16" || statuses[2] != "17" || statuses[3] != "6" {
+		t.Errorf("Unexpected check statuses: %v", statuses)
+	}
+	if v, _ := server.Verification(response.RequestId); v.Status != StatusFailed || v.Code != "abc123" {
+		t.Errorf("Unexpected verification: %+v", v)
+	}
+
+	again, _, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{})
+	if again.Status != "0" {
+		t.Errorf("A failed verification should allow a new request: %+v", again)
+	}
+}
+
+func TestControl(t *testing.T) {
+	server, client, clock := testServer()
+	defer server.Close()
+
+	response, _, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{WorkflowID: 5})
+	control, _, _ := client.Cancel(response.RequestId)
+	if control.Status != "19" {
+		t.Errorf("Cancel in the first 30 seconds should give status 19, got %s", control.Status)
+	}
+
+	clock.t = clock.t.Add(30 * time.Second)
+	control, _, _ = client.TriggerNextEvent(response.RequestId)
+	if control.Status != "0" || control.Command != "trigger_next_event" {
+		t.Errorf("Unexpected trigger: %+v", control)
+	}
+	control, _, _ = client.TriggerNextEvent(response.RequestId)
+	if control.Status != "19" {
+		t.Errorf("Workflow 5 has two events, got status %s", control.Status)
+	}
+	v, _ := server.Verification(response.RequestId)
+	if len(v.Events) != 2 || v.Events[1].Type != "tts" {
+		t.Errorf("Unexpected events: %+v", v.Events)
+	}
+	control, _, _ = client.Cancel(response.RequestId)
+	if control.Status != "19" {
+		t.Errorf("Cancel after the second event should give status 19, got %s", control.Status)
+	}
+
+	other, _, _ := client.Request("447700900001", "Acme", vonage.VerifyOpts{})
+	clock.t = clock.t.Add(30 * time.Second)
+	control, _, _ = client.Cancel(other.RequestId)
+	if control.Status != "0" || control.Command != "cancel" {
+		t.Errorf("Unexpected cancel: %+v", control)
+	}
+	if v, _ := server.Verification(other.RequestId); v.Status != StatusCancelled {
+		t.Errorf("Unexpected status after cancel: %s", v.Status)
+	}
+}
+
+func TestWorkflowAndExpiry(t *testing.T) {
+	server, client, clock := testServer()
+	defer server.Close()
+
+	response, _, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{PinExpiry: 180, NextEventWait: 60})
+	code, _ := server.Code(response.RequestId)
+
+	clock.t = clock.t.Add(150 * time.Second)
+	v, _ := server.Verification(response.RequestId)
+	if len(v.Events) != 3 || v.Events[1].Type != "tts" || !v.Events[2].Sent.Equal(v.Submitted.Add(120*time.Second)) {
+		t.Errorf("Unexpected events after 150 seconds: %+v", v.Events)
+	}
+
+	clock.t = clock.t.Add(30 * time.Second)
+	check, _, _ := client.Check(response.RequestId, code)
+	if check.Status != "6" {
+		t.Errorf("Check after expiry should give status 6, got %s", check.Status)
+	}
+	results, _, _ := client.SearchRequests(response.RequestId, "ffffffffffffffffffffffffffffffff")
+	if len(results) != 1 || results[0].Status != StatusExpired {
+		t.Errorf("Unexpected search after expiry: %+v", results)
+	}
+}
+
+func TestPsd2(t *testing.T) {
+	server, client, _ := testServer()
+	defer server.Close()
+	server.GenerateCode = func(length int) string { return "123456"[:length] }
+
+	response, _, err := client.Psd2("447700900000", "Acme Shop", 12.5, vonage.VerifyPsd2Opts{})
+	if err != nil || response.Status != "0" {
+		t.Fatalf("Unexpected PSD2 request: %v %+v", err, response)
+	}
+	v, _ := server.Verification(response.RequestId)
+	if v.Payee != "Acme Shop" || v.Amount != "12.5" || v.Code != "1234" {
+		t.Errorf("Unexpected PSD2 verification: %+v", v)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	server, client, _ := testServer()
+	defer server.Close()
+	server.ApiKey = "12345678"
+	server.ApiSecret = "other"
+
+	response, errResp, _ := client.Request("447700900000", "Acme", vonage.VerifyOpts{})
+	if response.Status != "4" || errResp.ErrorText != "Bad Credentials" {
+		t.Errorf("Wrong secret should give status 4: %+v", errResp)
+	}
+}
+
+func TestWithVerifySession(t *testing.T) {
+	server, client, _ := testServer()
+	defer server.Close()
+
+	sessions := verifysession.New(client, "Acme")
+	started, _ := sessions.Start("user-1", "447700900000")
+	resumed, _ := sessions.Start("user-1", "447700900000")
+	if started.Outcome != verifysession.OutcomeStarted || resumed.Outcome != verifysession.OutcomeResumed {
+		t.Errorf("Unexpected outcomes: %s then %s", started.Outcome, resumed.Outcome)
+	}
+
+	code, _ := server.LatestCode("447700900000")
+	result, err := sessions.Check("user-1", code)
+	if err != nil || result.Outcome != verifysession.OutcomeVerified {
+		t.Errorf("Unexpected check: %v %+v", err, result)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/antihax/optional"
 	"github.com/vonage/vonage-go-sdk/internal/voice"
 	"github.com/vonage/vonage-go-sdk/ncco"
+	"github.com/vonage/vonage-go-sdk/tts"
 )
 
 // VoiceClient for working with the Voice API
@@ -28,25 +33,193 @@ func NewVoiceClient(Auth Auth) *VoiceClient {
 	return client
 }
 
+// GetCallsOpts holds the filters for listing calls. Order is "asc" or
+// "desc" and PageSize can be up to 100
+type GetCallsOpts struct {
+	Status           string
+	DateStart        time.Time
+	DateEnd          time.Time
+	PageSize         int32
+	RecordIndex      int32
+	Order            string
+	ConversationUuid string
+}
+
+// callStatuses are the values that calls can be filtered by
+var callStatuses = []string{"started", "ringing", "answered", "machine", "completed", "busy", "cancelled", "failed", "rejected", "timeout", "unanswered"}
+
 // List your calls
 func (client *VoiceClient) GetCalls() (voice.GetCallsResponse, VoiceErrorResponse, error) {
+	return client.getCalls(context.Background(), GetCallsOpts{})
+}
+
+// GetCallsWithOpts lists the calls matching opts, one page at a time. Use
+// CallsIterator to walk all the pages
+func (client *VoiceClient) GetCallsWithOpts(opts GetCallsOpts) (voice.GetCallsResponse, VoiceErrorResponse, error) {
+	return client.getCalls(context.Background(), opts)
+}
+
+func (client *VoiceClient) getCalls(ctx context.Context, opts GetCallsOpts) (voice.GetCallsResponse, VoiceErrorResponse, error) {
 	// create the client
 	voiceClient := voice.NewAPIClient(client.Config)
 
 	// set up and then parse the options
 	voiceOpts := voice.GetCallsOpts{}
 
-	ctx := context.Background()
+	if opts.Status != "" {
+		if !containsString(callStatuses, opts.Status) {
+			return voice.GetCallsResponse{}, VoiceErrorResponse{}, errors.New("Unsupported call status: " + opts.Status)
+		}
+		voiceOpts.Status = optional.NewString(opts.Status)
+	}
+
+	if !opts.DateStart.IsZero() {
+		voiceOpts.DateStart = optional.NewTime(opts.DateStart)
+	}
+
+	if !opts.DateEnd.IsZero() {
+		voiceOpts.DateEnd = optional.NewTime(opts.DateEnd)
+	}
+
+	if opts.PageSize != 0 {
+		if opts.PageSize < 1 || opts.PageSize > 100 {
+			return voice.GetCallsResponse{}, VoiceErrorResponse{}, errors.New("PageSize must be between 1 and 100")
+		}
+		voiceOpts.PageSize = optional.NewInt32(opts.PageSize)
+	}
+
+	if opts.RecordIndex != 0 {
+		voiceOpts.RecordIndex = optional.NewInt32(opts.RecordIndex)
+	}
+
+	if opts.Order != "" {
+		if opts.Order != "asc" && opts.Order != "desc" {
+			return voice.GetCallsResponse{}, VoiceErrorResponse{}, errors.New("Order must be asc or desc")
+		}
+		voiceOpts.Order = optional.NewString(opts.Order)
+	}
+
+	if opts.ConversationUuid != "" {
+		voiceOpts.ConversationUuid = optional.NewInterface(opts.ConversationUuid)
+	}
+
 	result, _, err := voiceClient.CallsApi.GetCalls(ctx, &voiceOpts)
 
 	// catch HTTP errors
 	if err != nil {
+		e, ok := err.(voice.GenericOpenAPIError)
+		if ok {
+			var errResp VoiceErrorGeneralResponse
+			jsonErr := json.Unmarshal(e.Body(), &errResp)
+			if jsonErr == nil {
+				return voice.GetCallsResponse{}, VoiceErrorResponse{Error: errResp}, err
+			}
+		}
 		return voice.GetCallsResponse{}, VoiceErrorResponse{}, err
 	}
 
 	return result, VoiceErrorResponse{}, nil
 }
 
+// CallsIterator walks through every page of calls, following the links in
+// each response. Use it like a bufio.Scanner:
+//
+//	calls := client.CallsIterator(ctx, vonage.GetCallsOpts{Status: "completed"})
+//	for calls.Next() {
+//		call := calls.Call()
+//	}
+//	if err := calls.Err(); err != nil {
+//		// handle the error
+//	}
+type CallsIterator struct {
+	client  *VoiceClient
+	ctx     context.Context
+	opts    GetCallsOpts
+	page    []voice.GetCallResponse
+	index   int
+	current voice.GetCallResponse
+	done    bool
+	err     error
+	errResp VoiceErrorResponse
+}
+
+// CallsIterator returns an iterator over all the calls matching opts.
+// Cancel the context to stop fetching more pages
+func (client *VoiceClient) CallsIterator(ctx context.Context, opts GetCallsOpts) *CallsIterator {
+	return &CallsIterator{client: client, ctx: ctx, opts: opts}
+}
+
+// Next moves on to the next call, fetching another page when needed. It
+// returns false when there are no more calls or an error occurred
+func (it *CallsIterator) Next() bool {
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		it.fetch()
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+// fetch gets the next page and works out where the one after it starts
+func (it *CallsIterator) fetch() {
+	result, errResp, err := it.client.getCalls(it.ctx, it.opts)
+	if err != nil {
+		it.err = err
+		it.errResp = errResp
+		return
+	}
+
+	it.page = result.Embedded.Calls
+	it.index = 0
+
+	// no next link means this is the last page
+	next := result.Links.Next.Href
+	if next == "" || len(it.page) == 0 {
+		it.done = true
+		return
+	}
+
+	nextUrl, err := url.Parse(next)
+	if err != nil {
+		it.err = err
+		return
+	}
+	query := nextUrl.Query()
+	recordIndex, err := strconv.Atoi(query.Get("record_index"))
+	if err != nil || int32(recordIndex) <= it.opts.RecordIndex {
+		// the API didn't give us a usable next page, so stop rather than loop
+		it.done = true
+		return
+	}
+	it.opts.RecordIndex = int32(recordIndex)
+	if pageSize, err := strconv.Atoi(query.Get("page_size")); err == nil {
+		it.opts.PageSize = int32(pageSize)
+	}
+}
+
+// Call returns the current call
+func (it *CallsIterator) Call() voice.GetCallResponse {
+	return it.current
+}
+
+// Err returns the error that stopped the iterator, if any
+func (it *CallsIterator) Err() error {
+	return it.err
+}
+
+// ErrorResponse returns the API error details that came with Err, if any
+func (it *CallsIterator) ErrorResponse() VoiceErrorResponse {
+	return it.errResp
+}
+
 // GetCall for the details of a specific call
 func (client *VoiceClient) GetCall(uuid string) (voice.GetCallResponse, VoiceErrorResponse, error) {
 	// create the client
@@ -63,18 +236,32 @@ func (client *VoiceClient) GetCall(uuid string) (voice.GetCallResponse, VoiceErr
 	return result, VoiceErrorResponse{}, nil
 }
 
-// CreateCallOpts: Options for creating a call
+// CreateCallOpts: Options for creating a call. AdditionalTo holds any
+// endpoints to connect as well as To
 type CreateCallOpts struct {
-	From             CallFrom
-	To               CallTo
-	Ncco             ncco.Ncco
-	AnswerUrl        []string
-	AnswerMethod     string
-	EventUrl         []string
-	EventMethod      string
-	MachineDetection string
-	LengthTimer      int32
-	RingingTimer     int32
+	From                     CallFrom
+	RandomFromNumber         bool
+	To                       CallTo
+	AdditionalTo             []CallTo
+	Ncco                     ncco.Ncco
+	AnswerUrl                []string
+	AnswerMethod             string
+	FallbackUrl              []string
+	EventUrl                 []string
+	EventMethod              string
+	MachineDetection         string
+	AdvancedMachineDetection *AdvancedMachineDetection
+	LengthTimer              int32
+	RingingTimer             int32
+}
+
+// AdvancedMachineDetection configures voicemail detection. Behavior is
+// "continue" or "hangup", Mode is "default", "detect" or "detect_beep" and
+// BeepTimeout (45-120 seconds) is how long to wait for the voicemail beep
+type AdvancedMachineDetection struct {
+	Behavior    string
+	Mode        string
+	BeepTimeout int32
 }
 
 // CallFrom details of the caller
@@ -83,11 +270,64 @@ type CallFrom struct {
 	Number string
 }
 
-// CallTo details of the callee
+// CallTo details of the callee. Type is one of "phone" (the default),
+// "sip", "websocket", "app" or "vbc" and decides which other fields are used:
+// phone needs Number, sip and websocket need Uri, app needs User and vbc
+// needs Extension. Headers are sent with sip and websocket connections
 type CallTo struct {
-	Type       string
-	Number     string
-	DtmfAnswer string
+	Type            string
+	Number          string
+	DtmfAnswer      string
+	Uri             string
+	ContentType     string
+	Headers         map[string]string
+	StandardHeaders map[string]string
+	User            string
+	Extension       string
+}
+
+// isEmpty is true if none of the endpoint fields were set
+func (to CallTo) isEmpty() bool {
+	return to.Type == "" && to.Number == "" && to.Uri == "" && to.User == "" && to.Extension == ""
+}
+
+// endpoint converts CallTo into the endpoint struct for its type
+func (to CallTo) endpoint() (interface{}, error) {
+	switch to.Type {
+	case "", "phone":
+		if to.Number == "" {
+			return nil, errors.New("Phone endpoints need a Number")
+		}
+		return voice.EndpointPhoneTo{Type: "phone", Number: to.Number, DtmfAnswer: to.DtmfAnswer}, nil
+	case "sip":
+		if !strings.HasPrefix(to.Uri, "sip:") && !strings.HasPrefix(to.Uri, "sips:") {
+			return nil, errors.New("SIP endpoints need a Uri starting sip: or sips:")
+		}
+		return voice.EndpointSip{Type: "sip", Uri: to.Uri, Headers: to.Headers, StandardHeaders: to.StandardHeaders}, nil
+	case "websocket":
+		if !strings.HasPrefix(to.Uri, "ws://") && !strings.HasPrefix(to.Uri, "wss://") {
+			return nil, errors.New("Websocket endpoints need a Uri starting ws:// or wss://")
+		}
+		contentType := to.ContentType
+		if contentType == "" {
+			contentType = "audio/l16;rate=16000"
+		}
+		if contentType != "audio/l16;rate=8000" && contentType != "audio/l16;rate=16000" {
+			return nil, errors.New("Websocket ContentType must be audio/l16;rate=8000 or audio/l16;rate=16000")
+		}
+		return voice.EndpointWebsocket{Type: "websocket", Uri: to.Uri, ContentType: contentType, Headers: to.Headers}, nil
+	case "app":
+		if to.User == "" {
+			return nil, errors.New("App endpoints need a User")
+		}
+		return voice.EndpointApp{Type: "app", User: to.User}, nil
+	case "vbc":
+		if to.Extension == "" {
+			return nil, errors.New("VBC endpoints need an Extension")
+		}
+		return voice.EndpointVbcExtension{Type: "vbc", Extension: to.Extension}, nil
+	}
+	return nil, errors.New("Unsupported endpoint type: " + to.Type)
 }
 
 // VoiceErrorResponse is a container for error types since we can get more than
@@ -113,51 +353,129 @@ type VoiceErrorGeneralResponse struct {
 	Title string `json:"error_title,omitempty"`
 }
 
-func (client *VoiceClient) createCallCommon(opts CreateCallOpts) voice.CreateCallRequestBase {
+func (client *VoiceClient) createCallCommon(opts CreateCallOpts) (voice.CreateCallRequestBase, error) {
 
 	var target voice.CreateCallRequestBase
 
-	// assuming phone to start with, this needs other endpoints added later
-	var to []voice.EndpointPhoneTo
-	to_phone := voice.EndpointPhoneTo{Type: "phone", Number: opts.To.Number}
-	if opts.To.DtmfAnswer != "" {
-		to_phone.DtmfAnswer = opts.To.DtmfAnswer
+	// To is optional if AdditionalTo has all the endpoints in it
+	var callees []CallTo
+	if !opts.To.isEmpty() {
+		callees = append(callees, opts.To)
+	}
+	callees = append(callees, opts.AdditionalTo...)
+	if len(callees) == 0 {
+		return target, errors.New("No endpoint to call, supply To")
+	}
+
+	for _, callee := range callees {
+		endpoint, err := callee.endpoint()
+		if err != nil {
+			return target, err
+		}
+		target.To = append(target.To, endpoint)
 	}
-	to = append(to, to_phone)
-	target.To = to
 
-	// from has to be a phone
-	target.From = voice.EndpointPhoneFrom{Type: "phone", Number: opts.From.Number}
+	// from has to be a phone, or Vonage picks one of the application's numbers
+	if opts.RandomFromNumber {
+		if opts.From.Number != "" {
+			return target, errors.New("Supply either From or RandomFromNumber, not both")
+		}
+		target.RandomFromNumber = true
+	} else {
+		if opts.From.Number == "" {
+			return target, errors.New("No number to call from, supply From or set RandomFromNumber")
+		}
+		target.From = &voice.EndpointPhoneFrom{Type: "phone", Number: opts.From.Number}
+	}
 
 	// event settings
 	if len(opts.EventUrl) > 0 {
 		target.EventUrl = opts.EventUrl
 		if opts.EventMethod != "" {
+			if err := validateHttpMethod("EventMethod", opts.EventMethod); err != nil {
+				return target, err
+			}
 			target.EventMethod = opts.EventMethod
 		}
 	}
 
+	if opts.AnswerMethod != "" {
+		if err := validateHttpMethod("AnswerMethod", opts.AnswerMethod); err != nil {
+			return target, err
+		}
+	}
+
 	// other fields
 	if opts.MachineDetection != "" {
+		if opts.MachineDetection != "continue" && opts.MachineDetection != "hangup" {
+			return target, errors.New("MachineDetection must be continue or hangup")
+		}
 		target.MachineDetection = opts.MachineDetection
 	}
 
+	if opts.AdvancedMachineDetection != nil {
+		if opts.MachineDetection != "" {
+			return target, errors.New("Supply either MachineDetection or AdvancedMachineDetection, not both")
+		}
+		amd, err := opts.AdvancedMachineDetection.prepare()
+		if err != nil {
+			return target, err
+		}
+		target.AdvancedMachineDetection = &amd
+	}
+
 	if opts.RingingTimer != 0 {
+		if opts.RingingTimer < 1 || opts.RingingTimer > 120 {
+			return target, errors.New("RingingTimer must be between 1 and 120 seconds")
+		}
 		target.RingingTimer = opts.RingingTimer
 	}
 
 	if opts.LengthTimer != 0 {
+		if opts.LengthTimer < 1 || opts.LengthTimer > 7200 {
+			return target, errors.New("LengthTimer must be between 1 and 7200 seconds")
+		}
 		target.LengthTimer = opts.LengthTimer
 	}
 
-	return target
+	return target, nil
+}
+
+// validateHttpMethod checks the methods that webhooks can be requested with
+func validateHttpMethod(field string, method string) error {
+	if method != "GET" && method != "POST" {
+		return errors.New(field + " must be GET or POST")
+	}
+	return nil
+}
+
+// prepare for AdvancedMachineDetection checks the values and fills in
+// the default behaviour
+func (amd AdvancedMachineDetection) prepare() (voice.AdvancedMachineDetection, error) {
+	target := voice.AdvancedMachineDetection{Behavior: amd.Behavior, Mode: amd.Mode, BeepTimeout: amd.BeepTimeout}
+	if target.Behavior == "" {
+		target.Behavior = "continue"
+	}
+	if target.Behavior != "continue" && target.Behavior != "hangup" {
+		return target, errors.New("AdvancedMachineDetection Behavior must be continue or hangup")
+	}
+	if target.Mode != "" && target.Mode != "default" && target.Mode != "detect" && target.Mode != "detect_beep" {
+		return target, errors.New("AdvancedMachineDetection Mode must be default, detect or detect_beep")
+	}
+	if target.BeepTimeout != 0 && (target.BeepTimeout < 45 || target.BeepTimeout > 120) {
+		return target, errors.New("AdvancedMachineDetection BeepTimeout must be between 45 and 120 seconds")
+	}
+	return target, nil
 }
 
 // CreateCall Makes a phone call given the from/to details and an NCCO or an Answer URL
 func (client *VoiceClient) CreateCall(opts CreateCallOpts) (voice.CreateCallResponse, VoiceErrorResponse, error) {
 	voiceClient := voice.NewAPIClient(client.Config)
 	// use the same validation regardless of which type of call this is
-	commonFields := client.createCallCommon(opts)
+	commonFields, err := client.createCallCommon(opts)
+	if err != nil {
+		return voice.CreateCallResponse{}, VoiceErrorResponse{}, err
+	}
 
 	// ncco has its own features
 	if len(opts.Ncco.GetActions()) > 0 {
@@ -167,7 +485,9 @@ func (client *VoiceClient) CreateCall(opts CreateCallOpts) (voice.CreateCallResp
 		voiceCallOpts.From = commonFields.From
 		voiceCallOpts.EventUrl = commonFields.EventUrl
 		voiceCallOpts.EventMethod = commonFields.EventMethod
+		voiceCallOpts.RandomFromNumber = commonFields.RandomFromNumber
 		voiceCallOpts.MachineDetection = commonFields.MachineDetection
+		voiceCallOpts.AdvancedMachineDetection = commonFields.AdvancedMachineDetection
 		voiceCallOpts.LengthTimer = commonFields.LengthTimer
 		voiceCallOpts.RingingTimer = commonFields.RingingTimer
 
@@ -187,7 +507,9 @@ func (client *VoiceClient) CreateCall(opts CreateCallOpts) (voice.CreateCallResp
 		voiceCallOpts.From = commonFields.From
 		voiceCallOpts.EventUrl = commonFields.EventUrl
 		voiceCallOpts.EventMethod = commonFields.EventMethod
+		voiceCallOpts.RandomFromNumber = commonFields.RandomFromNumber
 		voiceCallOpts.MachineDetection = commonFields.MachineDetection
+		voiceCallOpts.AdvancedMachineDetection = commonFields.AdvancedMachineDetection
 		voiceCallOpts.LengthTimer = commonFields.LengthTimer
 		voiceCallOpts.RingingTimer = commonFields.RingingTimer
 
@@ -196,6 +518,9 @@ func (client *VoiceClient) CreateCall(opts CreateCallOpts) (voice.CreateCallResp
 		if opts.AnswerMethod != "" {
 			voiceCallOpts.AnswerMethod = opts.AnswerMethod
 		}
+		if len(opts.FallbackUrl) > 0 {
+			voiceCallOpts.FallbackUrl = opts.FallbackUrl
+		}
 
 		callOpts := optional.NewInterface(voiceCallOpts)
 
@@ -242,20 +567,33 @@ func (client *VoiceClient) handleCreateCallErrors(result voice.CreateCallRespons
 	return result, VoiceErrorResponse{}, nil
 }
 
-// TransferCallOpts: Options for transferring a call
+// TransferCallOpts: Options for transferring a call. Supply either
+// AnswerUrl or Ncco. EventUrl and FallbackUrl replace the call's event
+// and fallback URLs from the transfer onwards
 type TransferCallOpts struct {
-	Uuid      string
-	Ncco      ncco.Ncco
-	AnswerUrl []string
+	Uuid        string
+	Ncco        ncco.Ncco
+	AnswerUrl   []string
+	EventUrl    []string
+	EventMethod string
+	FallbackUrl []string
 }
 
+// ModifyCallResponse is returned by the Modify Call actions. The API
+// responds with no body, so Status is "0" for success along with the
+// action taken and the call it was applied to
 type ModifyCallResponse struct {
 	Status string
+	Uuid   string
+	Action string
 }
 
 type TransferDestinationUrl struct {
-	Type string   `json:"type"`
-	Url  []string `json:"url"`
+	Type        string   `json:"type"`
+	Url         []string `json:"url"`
+	EventUrl    []string `json:"eventUrl,omitempty"`
+	EventMethod string   `json:"eventMethod,omitempty"`
+	FallbackUrl []string `json:"fallbackUrl,omitempty"`
 }
 
 type TransferWithUrlOpts struct {
@@ -264,8 +602,11 @@ type TransferWithUrlOpts struct {
 }
 
 type TransferDestinationNcco struct {
-	Type string    `json:"type"`
-	Ncco ncco.Ncco `json:"ncco"`
+	Type        string    `json:"type"`
+	Ncco        ncco.Ncco `json:"ncco"`
+	EventUrl    []string  `json:"eventUrl,omitempty"`
+	EventMethod string    `json:"eventMethod,omitempty"`
+	FallbackUrl []string  `json:"fallbackUrl,omitempty"`
 }
 
 type TransferWithNccoOpts struct {
@@ -275,56 +616,37 @@ type TransferWithNccoOpts struct {
 
 // TransferCall wraps the Modify Call API endpoint
 func (client *VoiceClient) TransferCall(opts TransferCallOpts) (ModifyCallResponse, VoiceErrorResponse, error) {
-	// create the client
-	voiceClient := voice.NewAPIClient(client.Config)
+	if opts.EventMethod != "" {
+		if err := validateHttpMethod("EventMethod", opts.EventMethod); err != nil {
+			return ModifyCallResponse{}, VoiceErrorResponse{}, err
+		}
+	}
+
+	hasNcco := len(opts.Ncco.GetActions()) > 0
+	if len(opts.AnswerUrl) > 0 && hasNcco {
+		return ModifyCallResponse{}, VoiceErrorResponse{}, errors.New("Supply either an answer URL or an NCCO to transfer to, not both")
+	}
 
 	if len(opts.AnswerUrl) > 0 {
-		destination := TransferDestinationUrl{Type: "ncco", Url: opts.AnswerUrl}
-		transfer := TransferWithUrlOpts{Action: "transfer", Destination: destination}
-		modifyCallOpts := voice.ModifyCallOpts{Opts: optional.NewInterface(transfer)}
-		ctx := context.Background()
-		response, err := voiceClient.CallsApi.UpdateCall(ctx, opts.Uuid, &modifyCallOpts)
-		if err != nil {
-			e := err.(voice.GenericOpenAPIError)
-			data := e.Body()
-			errorType := e.Error()
-			if errorType == "400 Bad Request" {
-				var errResp VoiceErrorInvalidParamsResponse
-				jsonErr := json.Unmarshal(data, &errResp)
-				if jsonErr == nil {
-					return ModifyCallResponse{}, VoiceErrorResponse{Error: errResp}, err
-				}
-			}
-			return ModifyCallResponse{}, VoiceErrorResponse{Error: response}, err
-		} else {
-			// not a whole lot to return as it's a 204, this branch is success
-			return ModifyCallResponse{Status: "0"}, VoiceErrorResponse{}, nil
+		destination := TransferDestinationUrl{
+			Type:        "ncco",
+			Url:         opts.AnswerUrl,
+			EventUrl:    opts.EventUrl,
+			EventMethod: opts.EventMethod,
+			FallbackUrl: opts.FallbackUrl,
 		}
-	} else if len(opts.Ncco.GetActions()) > 0 {
-		destination := TransferDestinationNcco{Type: "ncco", Ncco: opts.Ncco}
-		transfer := TransferWithNccoOpts{Action: "transfer", Destination: destination}
-		modifyCallOpts := voice.ModifyCallOpts{Opts: optional.NewInterface(transfer)}
-		ctx := context.Background()
-		response, err := voiceClient.CallsApi.UpdateCall(ctx, opts.Uuid, &modifyCallOpts)
-		if err != nil {
-			e := err.(voice.GenericOpenAPIError)
-			data := e.Body()
-			errorType := e.Error()
-			if errorType == "400 Bad Request" {
-				var errResp VoiceErrorInvalidParamsResponse
-				jsonErr := json.Unmarshal(data, &errResp)
-				if jsonErr == nil {
-					return ModifyCallResponse{}, VoiceErrorResponse{Error: errResp}, err
-				}
-			}
-			return ModifyCallResponse{}, VoiceErrorResponse{Error: response}, err
-		} else {
-			// not a whole lot to return as it's a 204, this branch is success
-			return ModifyCallResponse{Status: "0"}, VoiceErrorResponse{}, nil
+		return client.modifyCall(opts.Uuid, "transfer", TransferWithUrlOpts{Action: "transfer", Destination: destination})
+	} else if hasNcco {
+		destination := TransferDestinationNcco{
+			Type:        "ncco",
+			Ncco:        opts.Ncco,
+			EventUrl:    opts.EventUrl,
+			EventMethod: opts.EventMethod,
+			FallbackUrl: opts.FallbackUrl,
 		}
+		return client.modifyCall(opts.Uuid, "transfer", TransferWithNccoOpts{Action: "transfer", Destination: destination})
 	}
 
-	// this is a backstop, we shouldn't end up here
 	return ModifyCallResponse{}, VoiceErrorResponse{}, errors.New("Unsupported combination of parameters, supply an answer URL or valid NCCO")
 }
 
@@ -359,30 +681,40 @@ func (client *VoiceClient) Unearmuff(uuid string) (ModifyCallResponse, VoiceErro
 
 // voiceAction holds the code for the actions that have no extra params
 func (client *VoiceClient) voiceAction(action string, uuid string) (ModifyCallResponse, VoiceErrorResponse, error) {
+	return client.modifyCall(uuid, action, ModifyCallOpts{Action: action})
+}
+
+// modifyCall sends any Modify Call request body and handles the errors
+func (client *VoiceClient) modifyCall(uuid string, action string, body interface{}) (ModifyCallResponse, VoiceErrorResponse, error) {
+	if uuid == "" {
+		return ModifyCallResponse{}, VoiceErrorResponse{}, errors.New("A call uuid is required")
+	}
+
 	// create the client
 	voiceClient := voice.NewAPIClient(client.Config)
-	modifyCallOpts := voice.ModifyCallOpts{Opts: optional.NewInterface(ModifyCallOpts{Action: action})}
+	modifyCallOpts := voice.ModifyCallOpts{Opts: optional.NewInterface(body)}
 	ctx := context.Background()
 
 	response, err := voiceClient.CallsApi.UpdateCall(ctx, uuid, &modifyCallOpts)
 	if err != nil {
-		e := err.(voice.GenericOpenAPIError)
-		data := e.Body()
-		errorType := e.Error()
-		if errorType == "400 Bad Request" {
+		e, ok := err.(voice.GenericOpenAPIError)
+		if ok && e.Error() == "400 Bad Request" {
 			var errResp VoiceErrorInvalidParamsResponse
-			jsonErr := json.Unmarshal(data, &errResp)
+			jsonErr := json.Unmarshal(e.Body(), &errResp)
 			if jsonErr == nil {
 				return ModifyCallResponse{}, VoiceErrorResponse{Error: errResp}, err
 			}
 		}
 		return ModifyCallResponse{}, VoiceErrorResponse{Error: response}, err
-	} else {
-		// not a whole lot to return as it's a 204, this branch is success
-		return ModifyCallResponse{Status: "0"}, VoiceErrorResponse{}, nil
 	}
+
+	// not a whole lot to return as it's a 204, this branch is success
+	return ModifyCallResponse{Status: "0", Uuid: uuid, Action: action}, VoiceErrorResponse{}, nil
 }
 
+// PlayAudioOpts: Options for streaming audio into a call. Loop works as
+// it does in the stream NCCO action: leave it empty to play once, or use
+// "0" to play until stopped. Level is the volume, from -1 to 1
 type PlayAudioOpts struct {
 	Loop  string
 	Level int
@@ -390,9 +722,20 @@ type PlayAudioOpts struct {
 
 // PlayAudioStream starts an audio file from a URL playing in a call
 func (client *VoiceClient) PlayAudioStream(uuid string, streamUrl string, opts PlayAudioOpts) (voice.StartStreamResponse, VoiceErrorResponse, error) {
+	loop, err := prepareLoop(opts.Loop)
+	if err != nil {
+		return voice.StartStreamResponse{}, VoiceErrorResponse{}, err
+	}
+	if err := validateLevel(float64(opts.Level)); err != nil {
+		return voice.StartStreamResponse{}, VoiceErrorResponse{}, err
+	}
+
 	voiceClient := voice.NewAPIClient(client.Config)
 
-	streamOpts := voice.StartStreamRequest{StreamUrl: []string{streamUrl}}
+	streamOpts := voice.StartStreamRequest{StreamUrl: []string{streamUrl}, Loop: loop}
+	if opts.Level != 0 {
+		streamOpts.Level = strconv.Itoa(opts.Level)
+	}
 
 	ctx := context.Background()
 	response, _, err := voiceClient.StreamAudioApi.StartStream(ctx, uuid, streamOpts)
@@ -411,6 +754,27 @@ func (client *VoiceClient) PlayAudioStream(uuid string, streamUrl string, opts P
 	return response, VoiceErrorResponse{}, err
 }
 
+// prepareLoop turns a loop string into the number of times to play, where
+// an empty string means once and "0" means until stopped
+func prepareLoop(loop string) (int32, error) {
+	if loop == "" {
+		return 1, nil
+	}
+	value, err := strconv.Atoi(loop)
+	if err != nil || value < 0 {
+		return 0, errors.New("Loop must be a number, 0 to repeat until stopped")
+	}
+	return int32(value), nil
+}
+
+// validateLevel checks a volume level is between -1 and 1
+func validateLevel(level float64) error {
+	if level < -1 || level > 1 {
+		return errors.New("Level must be between -1 and 1")
+	}
+	return nil
+}
+
 // StopAudioStream stops the currently-playing audio stream
 func (client *VoiceClient) StopAudioStream(uuid string) (voice.StopStreamResponse, VoiceErrorResponse, error) {
 	voiceClient := voice.NewAPIClient(client.Config)
@@ -431,19 +795,52 @@ func (client *VoiceClient) StopAudioStream(uuid string) (voice.StopStreamRespons
 	return response, VoiceErrorResponse{}, err
 }
 
+// LoopForever is the PlayTtsOpts Loop that repeats the text until it's
+// stopped
+const LoopForever int32 = -1
+
+// PlayTtsOpts: Options for playing text-to-speech into a call. Loop is the
+// number of times to play the text, 0 plays it once and LoopForever
+// repeats it until stopped. Level is the volume as a string from "-1" to
+// "1". Language (such as "en-GB") and Style choose the voice, and Premium
+// uses the premium version of it; VoiceName is deprecated,
+// tts.VoiceNameLanguage gives the Language to use instead
 type PlayTtsOpts struct {
 	Text      string
 	Loop      int32
 	Level     string
 	VoiceName string
+	Language  string
+	Style     int32
+	Premium   bool
 }
 
 // PlayTts starts playing TTS into the call
 func (client *VoiceClient) PlayTts(uuid string, text string, opts PlayTtsOpts) (voice.StartTalkResponse, VoiceErrorResponse, error) {
+	if opts.Loop < LoopForever {
+		return voice.StartTalkResponse{}, VoiceErrorResponse{}, errors.New("Loop must not be negative, use LoopForever to repeat until stopped")
+	}
+	if opts.Level != "" {
+		level, err := strconv.ParseFloat(opts.Level, 64)
+		if err != nil {
+			return voice.StartTalkResponse{}, VoiceErrorResponse{}, errors.New("Level must be a number between -1 and 1")
+		}
+		if err := validateLevel(level); err != nil {
+			return voice.StartTalkResponse{}, VoiceErrorResponse{}, err
+		}
+	}
+	if err := validateTtsVoice(text, opts); err != nil {
+		return voice.StartTalkResponse{}, VoiceErrorResponse{}, err
+	}
+
 	voiceClient := voice.NewAPIClient(client.Config)
 
-	req_vars := voice.StartTalkRequest{Text: text}
-	if opts.Loop != 0 {
+	// the API repeats forever for a loop of 0
+	req_vars := voice.StartTalkRequest{Text: text, Loop: 1}
+	switch {
+	case opts.Loop == LoopForever:
+		req_vars.Loop = 0
+	case opts.Loop > 0:
 		req_vars.Loop = opts.Loop
 	}
 	if opts.Level != "" {
@@ -452,6 +849,9 @@ func (client *VoiceClient) PlayTts(uuid string, text string, opts PlayTtsOpts) (
 	if opts.VoiceName != "" {
 		req_vars.VoiceName = voice.VoiceName(opts.VoiceName)
 	}
+	req_vars.Language = opts.Language
+	req_vars.Style = opts.Style
+	req_vars.Premium = opts.Premium
 	talkOpts := voice.StartTalkOpts{StartTalkRequest: optional.NewInterface(req_vars)}
 
 	ctx := context.Background()
@@ -471,6 +871,31 @@ func (client *VoiceClient) PlayTts(uuid string, text string, opts PlayTtsOpts) (
 	return response, VoiceErrorResponse{}, err
 }
 
+// validateTtsVoice checks the voice has a language and a style that isn't
+// negative, and that any SSML in the text is valid
+func validateTtsVoice(text string, opts PlayTtsOpts) error {
+	if tts.IsSSML(text) {
+		if err := tts.ValidateSSML(text); err != nil {
+			return err
+		}
+	}
+	if opts.VoiceName != "" {
+		if _, ok := tts.VoiceNameLanguage(opts.VoiceName); !ok {
+			return errors.New("Unknown VoiceName " + opts.VoiceName)
+		}
+		if opts.Language != "" {
+			return errors.New("Use Language and Style instead of VoiceName, not both")
+		}
+	}
+	if opts.Language != "" {
+		return tts.Voice{Language: opts.Language, Style: int(opts.Style)}.Validate()
+	}
+	if opts.Style != 0 || opts.Premium {
+		return errors.New("Style and Premium need a Language")
+	}
+	return nil
+}
+
 // StopTts stops the current TTS from playing
 func (client *VoiceClient) StopTts(uuid string) (voice.StopTalkResponse, VoiceErrorResponse, error) {
 	voiceClient := voice.NewAPIClient(client.Config)
@@ -0,0 +1,90 @@
+package vonage
+
+import (
+	"github.com/vonage/vonage-go-sdk/internal/voice"
+	"github.com/vonage/vonage-go-sdk/ncco"
+)
+
+// CallControl makes the Modify Call, stream, talk and DTMF requests for
+// one in-progress call, so the uuid only needs supplying once
+type CallControl struct {
+	client *VoiceClient
+	Uuid   string
+}
+
+// CallControl returns a CallControl for the call with this uuid
+func (client *VoiceClient) CallControl(uuid string) *CallControl {
+	return &CallControl{client: client, Uuid: uuid}
+}
+
+// Detail fetches the current details of the call
+func (c *CallControl) Detail() (voice.GetCallResponse, VoiceErrorResponse, error) {
+	return c.client.GetCall(c.Uuid)
+}
+
+// Hangup ends the call
+func (c *CallControl) Hangup() (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.client.Hangup(c.Uuid)
+}
+
+// Mute stops the other parties hearing this call
+func (c *CallControl) Mute() (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.client.Mute(c.Uuid)
+}
+
+// Unmute lets the other parties hear this call again
+func (c *CallControl) Unmute() (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.client.Unmute(c.Uuid)
+}
+
+// Earmuff stops this call hearing the other parties
+func (c *CallControl) Earmuff() (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.client.Earmuff(c.Uuid)
+}
+
+// Unearmuff lets this call hear the other parties again
+func (c *CallControl) Unearmuff() (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.client.Unearmuff(c.Uuid)
+}
+
+// Transfer moves the call to a new NCCO, any Uuid in opts is replaced
+// with this call's uuid
+func (c *CallControl) Transfer(opts TransferCallOpts) (ModifyCallResponse, VoiceErrorResponse, error) {
+	opts.Uuid = c.Uuid
+	return c.client.TransferCall(opts)
+}
+
+// TransferToUrl moves the call to the NCCO returned by answerUrl
+func (c *CallControl) TransferToUrl(answerUrl string) (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.Transfer(TransferCallOpts{AnswerUrl: []string{answerUrl}})
+}
+
+// TransferToNcco moves the call to the supplied NCCO
+func (c *CallControl) TransferToNcco(n ncco.Ncco) (ModifyCallResponse, VoiceErrorResponse, error) {
+	return c.Transfer(TransferCallOpts{Ncco: n})
+}
+
+// PlayAudioStream starts an audio file from a URL playing in the call
+func (c *CallControl) PlayAudioStream(streamUrl string, opts PlayAudioOpts) (voice.StartStreamResponse, VoiceErrorResponse, error) {
+	return c.client.PlayAudioStream(c.Uuid, streamUrl, opts)
+}
+
+// StopAudioStream stops the audio stream playing in the call
+func (c *CallControl) StopAudioStream() (voice.StopStreamResponse, VoiceErrorResponse, error) {
+	return c.client.StopAudioStream(c.Uuid)
+}
+
+// PlayTts starts playing text-to-speech into the call
+func (c *CallControl) PlayTts(text string, opts PlayTtsOpts) (voice.StartTalkResponse, VoiceErrorResponse, error) {
+	return c.client.PlayTts(c.Uuid, text, opts)
+}
+
+// StopTts stops the text-to-speech playing in the call
+func (c *CallControl) StopTts() (voice.StopTalkResponse, VoiceErrorResponse, error) {
+	return c.client.StopTts(c.Uuid)
+}
+
+// PlayDtmf plays a string of DTMF digits into the call
+func (c *CallControl) PlayDtmf(digits string) (voice.DtmfResponse, VoiceErrorResponse, error) {
+	return c.client.PlayDtmf(c.Uuid, digits)
+}
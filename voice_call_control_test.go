@@ -0,0 +1,149 @@
+package vonage
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// recordBody registers a responder that keeps the request body
+func recordBody(method string, url string, status int, response string, body *string) {
+	httpmock.RegisterResponder(method, url,
+		func(req *http.Request) (*http.Response, error) {
+			if req.Body != nil {
+				data, _ := ioutil.ReadAll(req.Body)
+				*body = strings.TrimSpace(string(data))
+			}
+			resp := httpmock.NewStringResponse(status, response)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+}
+
+func TestVoiceCallControlTransferWithEvents(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	recordBody("PUT", "https://api.nexmo.com/v1/calls/abcdef01-2222-3333-4444-9876543210ab", 204, "", &body)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	call := NewVoiceClient(auth).CallControl("abcdef01-2222-3333-4444-9876543210ab")
+
+	result, _, err := call.Transfer(TransferCallOpts{
+		AnswerUrl:   []string{"https://example.com/answer"},
+		EventUrl:    []string{"https://example.com/event"},
+		EventMethod: "POST",
+		FallbackUrl: []string{"https://example.com/fallback"},
+	})
+	if err != nil {
+		t.Fatalf("Voice transfer failed: %s", err)
+	}
+	if result.Status != "0" || result.Action != "transfer" || result.Uuid != "abcdef01-2222-3333-4444-9876543210ab" {
+		t.Errorf("Unexpected transfer result: %+v", result)
+	}
+
+	expected := `{"action":"transfer","destination":{"type":"ncco","url":["https://example.com/answer"],"eventUrl":["https://example.com/event"],"eventMethod":"POST","fallbackUrl":["https://example.com/fallback"]}}`
+	if body != expected {
+		t.Errorf("Unexpected JSON format for: transfer with events: %s", body)
+	}
+
+	if _, _, err := call.Transfer(TransferCallOpts{AnswerUrl: []string{"https://example.com/answer"}, EventMethod: "PUT"}); err == nil {
+		t.Errorf("Transfer with an invalid event method should fail")
+	}
+}
+
+func TestVoiceCallControlPlayAudio(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	recordBody("PUT", "https://api.nexmo.com/v1/calls/abcdef01-2222-3333-4444-9876543210ab/stream", 200,
+		`{"message": "Stream started", "uuid": "abcdef01-2222-3333-4444-9876543210ab"}`, &body)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	call := NewVoiceClient(auth).CallControl("abcdef01-2222-3333-4444-9876543210ab")
+
+	call.PlayAudioStream("https://example.com/music.mp3", PlayAudioOpts{Loop: "0", Level: -1})
+	if body != `{"stream_url":["https://example.com/music.mp3"],"loop":0,"level":"-1"}` {
+		t.Errorf("Unexpected JSON format for: stream looping forever: %s", body)
+	}
+
+	call.PlayAudioStream("https://example.com/music.mp3", PlayAudioOpts{})
+	if body != `{"stream_url":["https://example.com/music.mp3"],"loop":1}` {
+		t.Errorf("Unexpected JSON format for: stream with defaults: %s", body)
+	}
+
+	invalid := map[string]PlayAudioOpts{
+		"loop":  {Loop: "forever"},
+		"level": {Level: 2},
+	}
+	for name, opts := range invalid {
+		if _, _, err := call.PlayAudioStream("https://example.com/music.mp3", opts); err == nil {
+			t.Errorf("Stream with an invalid %s should fail", name)
+		}
+	}
+}
+
+func TestVoiceCallControlPlayTts(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	recordBody("PUT", "https://api.nexmo.com/v1/calls/abcdef01-2222-3333-4444-9876543210ab/talk", 200,
+		`{"message": "Talk started", "uuid": "abcdef01-2222-3333-4444-9876543210ab"}`, &body)
+	recordBody("DELETE", "https://api.nexmo.com/v1/calls/abcdef01-2222-3333-4444-9876543210ab/talk", 200,
+		`{"message": "Talk stopped", "uuid": "abcdef01-2222-3333-4444-9876543210ab"}`, new(string))
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	call := NewVoiceClient(auth).CallControl("abcdef01-2222-3333-4444-9876543210ab")
+
+	result, _, _ := call.PlayTts("Hello", PlayTtsOpts{Loop: 2, Level: "0.5", Language: "en-GB", Style: 3, Premium: true})
+	if result.Message != "Talk started" {
+		t.Errorf("Voice Start Talk failed")
+	}
+	if body != `{"text":"Hello","loop":2,"level":"0.5","language":"en-GB","style":3,"premium":true}` {
+		t.Errorf("Unexpected JSON format for: talk with a language and style: %s", body)
+	}
+
+	call.PlayTts("Hello", PlayTtsOpts{Loop: LoopForever})
+	if body != `{"text":"Hello","loop":0}` {
+		t.Errorf("Unexpected JSON format for: talk looping forever: %s", body)
+	}
+
+	call.PlayTts("Hello", PlayTtsOpts{})
+	if body != `{"text":"Hello","loop":1}` {
+		t.Errorf("Unexpected JSON format for: talk with defaults: %s", body)
+	}
+
+	stopped, _, _ := call.StopTts()
+	if stopped.Message != "Talk stopped" || stopped.Uuid != "abcdef01-2222-3333-4444-9876543210ab" {
+		t.Errorf("Voice Stop Talk failed: %+v", stopped)
+	}
+
+	invalid := map[string]PlayTtsOpts{
+		"loop":     {Loop: -2},
+		"level":    {Level: "loud"},
+		"range":    {Level: "-2"},
+		"style":    {Style: -1},
+		"voice":    {Language: "en-GB", Style: -2},
+		"language": {Language: "en-XX"},
+		"name":     {VoiceName: "Nicola"},
+	}
+	for name, opts := range invalid {
+		if _, _, err := call.PlayTts("Hello", opts); err == nil {
+			t.Errorf("Talk with an invalid %s should fail", name)
+		}
+	}
+}
+
+func TestVoiceCallControlRequiresUuid(t *testing.T) {
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	if _, _, err := NewVoiceClient(auth).CallControl("").Hangup(); err == nil {
+		t.Errorf("Hangup without a uuid should fail")
+	}
+}
@@ -0,0 +1,182 @@
+package vonage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vonage/vonage-go-sdk/internal/voice"
+)
+
+// conferenceConcurrency is the default number of requests a Conference
+// makes at once
+const conferenceConcurrency = 5
+
+// finishedCallStatuses are the statuses of legs that have left the call
+var finishedCallStatuses = []string{"completed", "busy", "cancelled", "failed", "rejected", "timeout", "unanswered"}
+
+// Conference manages all the call legs in one conversation, as created by
+// a ConversationAction. Moderators are the uuids of legs that MuteAll and
+// EarmuffAll leave alone
+type Conference struct {
+	client           *VoiceClient
+	ConversationUuid string
+	Moderators       []string
+	// Concurrency limits how many requests are made at once
+	Concurrency int
+}
+
+// Conference returns a helper for the conversation with this uuid
+func (client *VoiceClient) Conference(conversationUuid string, moderators ...string) *Conference {
+	return &Conference{
+		client:           client,
+		ConversationUuid: conversationUuid,
+		Moderators:       moderators,
+		Concurrency:      conferenceConcurrency,
+	}
+}
+
+// ConferenceLegResult is the outcome of an action on one leg
+type ConferenceLegResult struct {
+	Uuid          string
+	ErrorResponse VoiceErrorResponse
+	Error         error
+}
+
+// ConferenceResult holds the outcome for every leg an action was tried on
+type ConferenceResult struct {
+	Legs []ConferenceLegResult
+}
+
+// Failed returns the legs where the action failed
+func (r ConferenceResult) Failed() []ConferenceLegResult {
+	var failed []ConferenceLegResult
+	for _, leg := range r.Legs {
+		if leg.Error != nil {
+			failed = append(failed, leg)
+		}
+	}
+	return failed
+}
+
+// Err combines the errors from all the legs that failed, or is nil if
+// the action worked on every leg
+func (r ConferenceResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	messages := make([]string, len(failed))
+	for i, leg := range failed {
+		messages[i] = leg.Uuid + ": " + leg.Error.Error()
+	}
+	return fmt.Errorf("%d of %d legs failed: %s", len(failed), len(r.Legs), strings.Join(messages, "; "))
+}
+
+// Legs returns the call legs in the conversation that haven't finished
+func (c *Conference) Legs() ([]voice.GetCallResponse, VoiceErrorResponse, error) {
+	var legs []voice.GetCallResponse
+	calls := c.client.CallsIterator(context.Background(), GetCallsOpts{ConversationUuid: c.ConversationUuid})
+	for calls.Next() {
+		call := calls.Call()
+		if !containsString(finishedCallStatuses, call.Status) {
+			legs = append(legs, call)
+		}
+	}
+	return legs, calls.ErrorResponse(), calls.Err()
+}
+
+// MuteAll mutes every leg except the moderators
+func (c *Conference) MuteAll() (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(false, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.Mute(uuid)
+		return errResp, err
+	})
+}
+
+// UnmuteAll unmutes every leg except the moderators
+func (c *Conference) UnmuteAll() (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(false, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.Unmute(uuid)
+		return errResp, err
+	})
+}
+
+// EarmuffAll earmuffs every leg except the moderators
+func (c *Conference) EarmuffAll() (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(false, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.Earmuff(uuid)
+		return errResp, err
+	})
+}
+
+// UnearmuffAll unearmuffs every leg except the moderators
+func (c *Conference) UnearmuffAll() (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(false, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.Unearmuff(uuid)
+		return errResp, err
+	})
+}
+
+// Broadcast plays text-to-speech into every leg, including the moderators
+func (c *Conference) Broadcast(text string, opts PlayTtsOpts) (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(true, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.PlayTts(uuid, text, opts)
+		return errResp, err
+	})
+}
+
+// BroadcastAudio streams an audio file into every leg, including the
+// moderators
+func (c *Conference) BroadcastAudio(streamUrl string, opts PlayAudioOpts) (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(true, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.PlayAudioStream(uuid, streamUrl, opts)
+		return errResp, err
+	})
+}
+
+// End hangs up every leg, including the moderators
+func (c *Conference) End() (ConferenceResult, VoiceErrorResponse, error) {
+	return c.each(true, func(uuid string) (VoiceErrorResponse, error) {
+		_, errResp, err := c.client.Hangup(uuid)
+		return errResp, err
+	})
+}
+
+// each runs action on the legs concurrently. The error returned is from
+// listing the legs, errors from the action are in the ConferenceResult
+func (c *Conference) each(includeModerators bool, action func(uuid string) (VoiceErrorResponse, error)) (ConferenceResult, VoiceErrorResponse, error) {
+	legs, errResp, err := c.Legs()
+	if err != nil {
+		return ConferenceResult{}, errResp, err
+	}
+
+	var uuids []string
+	for _, leg := range legs {
+		if includeModerators || !containsString(c.Moderators, leg.Uuid) {
+			uuids = append(uuids, leg.Uuid)
+		}
+	}
+
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	results := make([]ConferenceLegResult, len(uuids))
+	var wg sync.WaitGroup
+	for i, uuid := range uuids {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, uuid string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			legErrResp, legErr := action(uuid)
+			results[i] = ConferenceLegResult{Uuid: uuid, ErrorResponse: legErrResp, Error: legErr}
+		}(i, uuid)
+	}
+	wg.Wait()
+
+	return ConferenceResult{Legs: results}, VoiceErrorResponse{}, nil
+}
@@ -0,0 +1,113 @@
+package vonage
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func registerConferenceLegs() {
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("conversation_uuid") != "CON-1" {
+				return httpmock.NewStringResponse(400, ""), nil
+			}
+			resp := httpmock.NewStringResponse(200, `{"count": 4, "page_size": 10, "record_index": 0,
+  "_links": {"self": {"href": "/calls?page_size=10&record_index=0"}},
+  "_embedded": {"calls": [
+    {"uuid": "leg-1", "conversation_uuid": "CON-1", "status": "answered"},
+    {"uuid": "leg-2", "conversation_uuid": "CON-1", "status": "answered"},
+    {"uuid": "leg-3", "conversation_uuid": "CON-1", "status": "completed"},
+    {"uuid": "leg-4", "conversation_uuid": "CON-1", "status": "answered"}
+  ]}}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+}
+
+func TestVoiceConferenceMuteAll(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerConferenceLegs()
+
+	var mu sync.Mutex
+	muted := map[string]bool{}
+	for _, uuid := range []string{"leg-1", "leg-2", "leg-4"} {
+		uuid := uuid
+		httpmock.RegisterResponder("PUT", "https://api.nexmo.com/v1/calls/"+uuid,
+			func(req *http.Request) (*http.Response, error) {
+				body, _ := ioutil.ReadAll(req.Body)
+				mu.Lock()
+				muted[uuid] = strings.Contains(string(body), `"action":"mute"`)
+				mu.Unlock()
+				if uuid == "leg-4" {
+					return httpmock.NewStringResponse(400, `{"type": "https://developer.nexmo.com/api-errors#bad-request", "title": "Bad Request", "invalid_parameters": []}`), nil
+				}
+				return httpmock.NewStringResponse(204, ""), nil
+			},
+		)
+	}
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+	conference := client.Conference("CON-1", "leg-1")
+
+	result, _, err := conference.MuteAll()
+	if err != nil {
+		t.Fatalf("Listing conference legs failed: %s", err)
+	}
+
+	if len(result.Legs) != 2 || result.Legs[0].Uuid != "leg-2" || result.Legs[1].Uuid != "leg-4" {
+		t.Errorf("Unexpected legs muted: %+v", result.Legs)
+	}
+	if muted["leg-1"] || !muted["leg-2"] || !muted["leg-4"] {
+		t.Errorf("Moderator should not be muted: %v", muted)
+	}
+	if len(result.Failed()) != 1 || result.Failed()[0].Uuid != "leg-4" {
+		t.Errorf("Expected leg-4 to fail: %+v", result.Failed())
+	}
+	if result.Err() == nil || !strings.HasPrefix(result.Err().Error(), "1 of 2 legs failed: leg-4: ") {
+		t.Errorf("Unexpected aggregated error: %v", result.Err())
+	}
+}
+
+func TestVoiceConferenceEnd(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerConferenceLegs()
+
+	httpmock.RegisterResponder("PUT", `=~^https://api.nexmo.com/v1/calls/leg-\d$`,
+		httpmock.NewStringResponder(204, ""))
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+	conference := client.Conference("CON-1", "leg-1")
+	conference.Concurrency = 1
+
+	result, _, err := conference.End()
+	if err != nil || result.Err() != nil {
+		t.Fatalf("Ending the conference failed: %v %v", err, result.Err())
+	}
+	if len(result.Legs) != 3 {
+		t.Errorf("All active legs should be hung up, including moderators: %+v", result.Legs)
+	}
+}
+
+func TestVoiceConferenceListError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerConferenceLegs()
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	_, _, err := client.Conference("CON-2").Broadcast("Hello", PlayTtsOpts{})
+	if err == nil {
+		t.Errorf("Broadcast should fail when the legs can't be listed")
+	}
+}
@@ -0,0 +1,257 @@
+package vonage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vonage/vonage-go-sdk/voicewebhook"
+)
+
+// DownloadRecordingOpts picks part of a recording to download. Offset is
+// the byte to start from, for resuming a download, and Length limits how
+// many bytes are fetched; 0 fetches the rest of the file
+type DownloadRecordingOpts struct {
+	Offset int64
+	Length int64
+}
+
+// RecordingDownload describes a finished download. Size is the size of
+// the whole recording, when the server reports it
+type RecordingDownload struct {
+	ContentType string
+	Written     int64
+	Size        int64
+	Resumed     bool
+}
+
+// VoiceTranscription is the result of transcribing a recording, with one
+// channel per channel recorded
+type VoiceTranscription struct {
+	Channels []VoiceTranscriptionChannel `json:"channels"`
+}
+
+// VoiceTranscriptionChannel holds the sentences heard in one channel
+type VoiceTranscriptionChannel struct {
+	Transcript []VoiceTranscriptSentence `json:"transcript"`
+	Duration   int                       `json:"duration,omitempty"`
+}
+
+// VoiceTranscriptSentence is one sentence, with timings for each word
+type VoiceTranscriptSentence struct {
+	Sentence    string                `json:"sentence"`
+	RawSentence string                `json:"raw_sentence,omitempty"`
+	Duration    int                   `json:"duration,omitempty"`
+	Timestamp   int                   `json:"timestamp,omitempty"`
+	Words       []VoiceTranscriptWord `json:"words,omitempty"`
+}
+
+// VoiceTranscriptWord is one word, with times in seconds from the start
+type VoiceTranscriptWord struct {
+	Word       string  `json:"word"`
+	StartTime  float64 `json:"start_time"`
+	EndTime    float64 `json:"end_time"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Text joins all the sentences of every channel
+func (t VoiceTranscription) Text() string {
+	var sentences []string
+	for _, channel := range t.Channels {
+		for _, sentence := range channel.Transcript {
+			sentences = append(sentences, sentence.Sentence)
+		}
+	}
+	return strings.Join(sentences, " ")
+}
+
+// ParseVoiceRecordEvent reads the body of the webhook sent when a
+// recording is ready
+func ParseVoiceRecordEvent(body io.Reader) (voicewebhook.RecordEvent, error) {
+	var event voicewebhook.RecordEvent
+	err := json.NewDecoder(body).Decode(&event)
+	if err == nil && event.RecordingUrl == "" {
+		err = errors.New("Record event has no recording_url")
+	}
+	return event, err
+}
+
+// ParseVoiceTranscriptionEvent reads the body of the webhook sent when a
+// transcription is ready
+func ParseVoiceTranscriptionEvent(body io.Reader) (voicewebhook.TranscriptionEvent, error) {
+	var event voicewebhook.TranscriptionEvent
+	err := json.NewDecoder(body).Decode(&event)
+	if err == nil && event.TranscriptionUrl == "" {
+		err = errors.New("Transcription event has no transcription_url")
+	}
+	return event, err
+}
+
+// DownloadRecording streams the recording at recordingUrl into w, without
+// holding the whole file in memory. With an Offset, an error is returned
+// if the server sends the whole file instead, or if the Offset is past
+// the end of the recording
+func (client *VoiceClient) DownloadRecording(recordingUrl string, w io.Writer, opts DownloadRecordingOpts) (RecordingDownload, VoiceErrorResponse, error) {
+	return client.download(context.Background(), recordingUrl, w, opts)
+}
+
+// DownloadRecordingToFile saves the recording to path. If the file is
+// already there, only the rest of the recording is fetched, so an
+// interrupted download can be resumed by calling this again
+func (client *VoiceClient) DownloadRecordingToFile(recordingUrl string, path string) (RecordingDownload, VoiceErrorResponse, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return RecordingDownload{}, VoiceErrorResponse{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return RecordingDownload{}, VoiceErrorResponse{}, err
+	}
+	offset := info.Size()
+
+	// writer starts at the end of the file, unless the server sends the
+	// whole recording again
+	writer := &recordingFileWriter{file: file, offset: offset}
+	return client.download(context.Background(), recordingUrl, writer, DownloadRecordingOpts{Offset: offset})
+}
+
+// recordingFileWriter writes from offset, or from the start if restart
+// is called because the server ignored the range
+type recordingFileWriter struct {
+	file    *os.File
+	offset  int64
+	started bool
+}
+
+func (w *recordingFileWriter) restart() {
+	w.offset = 0
+}
+
+func (w *recordingFileWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		if err := w.file.Truncate(w.offset); err != nil {
+			return 0, err
+		}
+		if _, err := w.file.Seek(w.offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
+
+// GetTranscription fetches the transcription from the transcription_url
+// in a transcription event
+func (client *VoiceClient) GetTranscription(transcriptionUrl string) (VoiceTranscription, VoiceErrorResponse, error) {
+	var transcription VoiceTranscription
+	var body strings.Builder
+	_, errResp, err := client.download(context.Background(), transcriptionUrl, &body, DownloadRecordingOpts{})
+	if err != nil {
+		return transcription, errResp, err
+	}
+	err = json.Unmarshal([]byte(body.String()), &transcription)
+	return transcription, VoiceErrorResponse{}, err
+}
+
+// download fetches a file that needs JWT auth, such as a recording or a
+// transcription, streaming it into w
+func (client *VoiceClient) download(ctx context.Context, fileUrl string, w io.Writer, opts DownloadRecordingOpts) (RecordingDownload, VoiceErrorResponse, error) {
+	var download RecordingDownload
+
+	// the JWT should only ever be sent to Vonage
+	target, err := url.Parse(fileUrl)
+	if err != nil {
+		return download, VoiceErrorResponse{}, err
+	}
+	host := target.Hostname()
+	if target.Scheme != "https" || !(strings.HasSuffix(host, ".nexmo.com") || strings.HasSuffix(host, ".vonage.com")) {
+		return download, VoiceErrorResponse{}, errors.New("Download URL must be an https URL on a Vonage API host")
+	}
+	if opts.Offset < 0 || opts.Length < 0 {
+		return download, VoiceErrorResponse{}, errors.New("Offset and Length can't be negative")
+	}
+
+	req, err := http.NewRequest("GET", fileUrl, nil)
+	if err != nil {
+		return download, VoiceErrorResponse{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+client.JWT)
+	req.Header.Set("User-Agent", client.Config.UserAgent)
+	if opts.Offset > 0 || opts.Length > 0 {
+		rangeHeader := fmt.Sprintf("bytes=%d-", opts.Offset)
+		if opts.Length > 0 {
+			rangeHeader += strconv.FormatInt(opts.Offset+opts.Length-1, 10)
+		}
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	httpClient := client.Config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return download, VoiceErrorResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the whole file, either no range was asked for or it was ignored.
+		// Only a file can be written again from the start
+		if opts.Offset > 0 {
+			restarter, ok := w.(*recordingFileWriter)
+			if !ok {
+				return download, VoiceErrorResponse{}, errors.New("Server ignored the range, the download can't start from Offset")
+			}
+			restarter.restart()
+		}
+		download.Size = resp.ContentLength
+	case http.StatusPartialContent:
+		download.Resumed = opts.Offset > 0
+		download.Size = contentRangeSize(resp.Header.Get("Content-Range"))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// asked to start at the end, so there's nothing left. Any other
+		// offset doesn't match the file on the server
+		download.Size = contentRangeSize(resp.Header.Get("Content-Range"))
+		if download.Size != opts.Offset {
+			return download, VoiceErrorResponse{}, fmt.Errorf("Offset %d doesn't match the recording size %d", opts.Offset, download.Size)
+		}
+		return download, VoiceErrorResponse{}, nil
+	default:
+		data, _ := ioutil.ReadAll(resp.Body)
+		var errResp VoiceErrorGeneralResponse
+		if jsonErr := json.Unmarshal(data, &errResp); jsonErr == nil {
+			return download, VoiceErrorResponse{Error: errResp}, errors.New(resp.Status)
+		}
+		return download, VoiceErrorResponse{}, errors.New(resp.Status)
+	}
+
+	download.ContentType = resp.Header.Get("Content-Type")
+	download.Written, err = io.Copy(w, resp.Body)
+	return download, VoiceErrorResponse{}, err
+}
+
+// contentRangeSize reads the total size from "bytes 0-99/1234" or "bytes */1234"
+func contentRangeSize(contentRange string) int64 {
+	slash := strings.LastIndex(contentRange, "/")
+	if slash < 0 {
+		return -1
+	}
+	size, err := strconv.ParseInt(contentRange[slash+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
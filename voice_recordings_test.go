@@ -0,0 +1,182 @@
+package vonage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const testRecordingUrl = "https://api.nexmo.com/v1/files/aaaaaaaa-bbbb-cccc-dddd-0123456789ab"
+
+// registerRecording serves "0123456789" and honours Range headers
+func registerRecording(t *testing.T) {
+	content := "0123456789"
+	httpmock.RegisterResponder("GET", testRecordingUrl,
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer a.b.c" {
+				t.Errorf("Recording requested without the JWT")
+			}
+			rangeHeader := req.Header.Get("Range")
+			if rangeHeader == "" {
+				resp := httpmock.NewStringResponse(200, content)
+				resp.Header.Add("Content-Type", "audio/mpeg")
+				resp.ContentLength = int64(len(content))
+				return resp, nil
+			}
+
+			parts := strings.SplitN(strings.TrimPrefix(rangeHeader, "bytes="), "-", 2)
+			start := atoiOr(parts[0], 0)
+			end := atoiOr(parts[1], len(content)-1)
+			if start >= len(content) {
+				resp := httpmock.NewStringResponse(416, "")
+				resp.Header.Add("Content-Range", "bytes */10")
+				return resp, nil
+			}
+			resp := httpmock.NewStringResponse(206, content[start:end+1])
+			resp.Header.Add("Content-Type", "audio/mpeg")
+			resp.Header.Add("Content-Range", "bytes "+parts[0]+"-"+parts[1]+"/10")
+			return resp, nil
+		},
+	)
+}
+
+func atoiOr(s string, fallback int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func TestVoiceDownloadRecording(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerRecording(t)
+
+	client := NewVoiceClient(&JWTAuth{JWT: "a.b.c"})
+
+	var buf bytes.Buffer
+	download, _, err := client.DownloadRecording(testRecordingUrl, &buf, DownloadRecordingOpts{})
+	if err != nil {
+		t.Fatalf("Recording download failed: %s", err)
+	}
+	if buf.String() != "0123456789" || download.Written != 10 || download.ContentType != "audio/mpeg" {
+		t.Errorf("Unexpected download: %+v %q", download, buf.String())
+	}
+
+	buf.Reset()
+	download, _, err = client.DownloadRecording(testRecordingUrl, &buf, DownloadRecordingOpts{Offset: 2, Length: 3})
+	if err != nil || buf.String() != "234" || download.Size != 10 || !download.Resumed {
+		t.Errorf("Unexpected range download: %+v %q %v", download, buf.String(), err)
+	}
+}
+
+func TestVoiceDownloadRecordingToFileResume(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerRecording(t)
+
+	dir, _ := ioutil.TempDir("", "recording")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "call.mp3")
+	ioutil.WriteFile(path, []byte("01234"), 0644)
+
+	client := NewVoiceClient(&JWTAuth{JWT: "a.b.c"})
+	download, _, err := client.DownloadRecordingToFile(testRecordingUrl, path)
+	if err != nil {
+		t.Fatalf("Recording download failed: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != "0123456789" || download.Written != 5 || !download.Resumed {
+		t.Errorf("Download should resume from the end of the file: %+v %q", download, data)
+	}
+
+	// the file is complete, so nothing more is fetched
+	download, _, err = client.DownloadRecordingToFile(testRecordingUrl, path)
+	data, _ = ioutil.ReadFile(path)
+	if err != nil || download.Written != 0 || download.Size != 10 || string(data) != "0123456789" {
+		t.Errorf("Complete file should be left alone: %+v %q %v", download, data, err)
+	}
+}
+
+func TestVoiceDownloadRecordingErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testRecordingUrl,
+		httpmock.NewStringResponder(404, `{"type": "NOT_FOUND", "error_title": "Not Found"}`))
+
+	client := NewVoiceClient(&JWTAuth{JWT: "a.b.c"})
+	var buf bytes.Buffer
+
+	_, errResp, err := client.DownloadRecording(testRecordingUrl, &buf, DownloadRecordingOpts{})
+	if err == nil || errResp.Error.(VoiceErrorGeneralResponse).Title != "Not Found" {
+		t.Errorf("Missing recording should return the error response: %v %+v", err, errResp)
+	}
+
+	_, _, err = client.DownloadRecording("https://example.com/files/abc", &buf, DownloadRecordingOpts{})
+	if err == nil {
+		t.Errorf("The JWT should not be sent to other hosts")
+	}
+}
+
+func TestVoiceDownloadRecordingRangeMismatch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerRecording(t)
+
+	client := NewVoiceClient(&JWTAuth{JWT: "a.b.c"})
+	var buf bytes.Buffer
+
+	// past the end of the recording, so a local file can't be part of it
+	download, _, err := client.DownloadRecording(testRecordingUrl, &buf, DownloadRecordingOpts{Offset: 12})
+	if err == nil || download.Size != 10 {
+		t.Errorf("Offset past the end should return an error: %+v %v", download, err)
+	}
+
+	// a server that ignores the range sends the whole file, which can't
+	// be written into a plain writer from the offset
+	httpmock.RegisterResponder("GET", testRecordingUrl, httpmock.NewStringResponder(200, "0123456789"))
+	_, _, err = client.DownloadRecording(testRecordingUrl, &buf, DownloadRecordingOpts{Offset: 5})
+	if err == nil || buf.Len() != 0 {
+		t.Errorf("Ignored range should return an error: %q %v", buf.String(), err)
+	}
+}
+
+func TestVoiceRecordEventAndTranscription(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	event, err := ParseVoiceRecordEvent(strings.NewReader(`{"start_time": "2020-01-01T12:00:00Z", "recording_url": "` + testRecordingUrl + `", "size": 12222, "recording_uuid": "rec-1", "end_time": "2020-01-01T12:01:00Z", "conversation_uuid": "CON-1", "timestamp": "2020-01-01T14:00:00.000Z"}`))
+	if err != nil || event.Size != 12222 || event.EndTime.Sub(event.StartTime).Seconds() != 60 {
+		t.Errorf("Unexpected record event: %+v %v", event, err)
+	}
+
+	transcriptionEvent, err := ParseVoiceTranscriptionEvent(strings.NewReader(`{"conversation_uuid": "CON-1", "type": "transcription", "recording_uuid": "rec-1", "status": "completed", "transcription_url": "https://api.nexmo.com/v1/files/trans-1"}`))
+	if err != nil || transcriptionEvent.RecordingUuid != "rec-1" {
+		t.Errorf("Unexpected transcription event: %+v %v", transcriptionEvent, err)
+	}
+
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/v1/files/trans-1",
+		httpmock.NewStringResponder(200, `{"channels": [{"duration": 4, "transcript": [{"sentence": "Hello there.", "words": [{"word": "Hello", "start_time": 0.1, "end_time": 0.5, "confidence": 0.97}, {"word": "there", "start_time": 0.6, "end_time": 0.9, "confidence": 0.88}]}, {"sentence": "Goodbye."}]}]}`))
+
+	client := NewVoiceClient(&JWTAuth{JWT: "a.b.c"})
+	transcription, _, err := client.GetTranscription(transcriptionEvent.TranscriptionUrl)
+	if err != nil {
+		t.Fatalf("Transcription download failed: %s", err)
+	}
+	if transcription.Text() != "Hello there. Goodbye." || transcription.Channels[0].Transcript[0].Words[1].Confidence != 0.88 {
+		t.Errorf("Unexpected transcription: %+v", transcription)
+	}
+
+	if _, err := ParseVoiceRecordEvent(strings.NewReader(`{"status": "completed"}`)); err == nil {
+		t.Errorf("Event without a recording_url is not a record event")
+	}
+}
@@ -1,8 +1,13 @@
 package vonage
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/vonage/vonage-go-sdk/ncco"
@@ -101,6 +106,97 @@ func TestVoiceGetCallsNoAuth(t *testing.T) {
 		t.Errorf("Voice GetCalls with faily Auth failed")
 	}
 }
+func TestVoiceGetCallsWithFilters(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var query url.Values
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			query = req.URL.Query()
+			resp := httpmock.NewStringResponse(200, `{"count": 0, "page_size": 10, "record_index": 0}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := GetCallsOpts{Status: "completed", DateStart: start, PageSize: 50, Order: "desc", ConversationUuid: "CON-f972836a-550f-45fa-956c-12a2ab5b7d22"}
+	_, _, err := client.GetCallsWithOpts(opts)
+	if err != nil {
+		t.Errorf("Voice GetCalls with filters failed: %s", err)
+	}
+
+	if query.Get("status") != "completed" || query.Get("date_start") != "2020-01-01T12:00:00Z" || query.Get("page_size") != "50" ||
+		query.Get("order") != "desc" || query.Get("conversation_uuid") != "CON-f972836a-550f-45fa-956c-12a2ab5b7d22" {
+		t.Errorf("Voice GetCalls filters not sent: %v", query)
+	}
+
+	_, _, err = client.GetCallsWithOpts(GetCallsOpts{Order: "newest"})
+	if err == nil {
+		t.Errorf("Voice GetCalls with a bad order should fail")
+	}
+}
+
+func TestVoiceCallsIterator(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			var body string
+			if req.URL.Query().Get("record_index") == "" {
+				body = `{"count": 3, "page_size": 2, "record_index": 0,
+  "_links": {"self": {"href": "/calls?page_size=2&record_index=0"}, "next": {"href": "/calls?page_size=2&record_index=2"}},
+  "_embedded": {"calls": [{"uuid": "call-1"}, {"uuid": "call-2"}]}}`
+			} else {
+				body = `{"count": 3, "page_size": 2, "record_index": 2,
+  "_links": {"self": {"href": "/calls?page_size=2&record_index=2"}},
+  "_embedded": {"calls": [{"uuid": "call-3"}]}}`
+			}
+			resp := httpmock.NewStringResponse(200, body)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	var uuids []string
+	calls := client.CallsIterator(context.Background(), GetCallsOpts{PageSize: 2})
+	for calls.Next() {
+		uuids = append(uuids, calls.Call().Uuid)
+	}
+
+	if calls.Err() != nil {
+		t.Errorf("Voice calls iterator failed: %s", calls.Err())
+	}
+
+	if strings.Join(uuids, ",") != "call-1,call-2,call-3" {
+		t.Errorf("Voice calls iterator did not walk all pages: %v", uuids)
+	}
+}
+
+func TestVoiceCallsIteratorCancel(t *testing.T) {
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := client.CallsIterator(ctx, GetCallsOpts{})
+	if calls.Next() {
+		t.Errorf("Voice calls iterator should not continue after cancel")
+	}
+	if calls.Err() != context.Canceled {
+		t.Errorf("Voice calls iterator should report the cancellation")
+	}
+}
+
 func TestVoiceGetCall(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -223,6 +319,149 @@ func TestVoiceMakeCallWithAnswerUrl(t *testing.T) {
 	}
 }
 
+func TestVoiceMakeCallToEndpoints(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			resp := httpmock.NewStringResponse(201, `
+{
+  "uuid": "63f61863-4a51-4f6b-86e1-46edebcf9356",
+  "status": "started",
+  "direction": "outbound",
+  "conversation_uuid": "CON-f972836a-550f-45fa-956c-12a2ab5b7d22"
+}
+	`,
+			)
+
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	from := CallFrom{Type: "phone", Number: "447770007777"}
+	to := CallTo{Type: "websocket", Uri: "wss://example.com/socket", ContentType: "audio/l16;rate=8000", Headers: map[string]string{"customer": "abc"}}
+	more := []CallTo{
+		{Type: "sip", Uri: "sip:rebekka@sip.example.com", Headers: map[string]string{"location": "london"}},
+		{Type: "app", User: "jamie"},
+		{Type: "vbc", Extension: "1234"},
+	}
+	answer := []string{"https://example.com/answer"}
+
+	_, _, err := client.CreateCall(CreateCallOpts{From: from, To: to, AdditionalTo: more, AnswerUrl: answer})
+	if err != nil {
+		t.Errorf("Voice create call to endpoints failed: %s", err)
+	}
+
+	expected := `"to":[{"type":"websocket","uri":"wss://example.com/socket","content-type":"audio/l16;rate=8000","headers":{"customer":"abc"}},{"type":"sip","uri":"sip:rebekka@sip.example.com","headers":{"location":"london"}},{"type":"app","user":"jamie"},{"type":"vbc","extension":"1234"}]`
+	if !strings.Contains(body, expected) {
+		t.Errorf("Unexpected JSON format for: create call endpoints: %s", body)
+	}
+}
+
+func TestVoiceMakeCallBadEndpoint(t *testing.T) {
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	from := CallFrom{Type: "phone", Number: "447770007777"}
+	answer := []string{"https://example.com/answer"}
+
+	_, _, err := client.CreateCall(CreateCallOpts{From: from, To: CallTo{Type: "sip", Uri: "example.com"}, AnswerUrl: answer})
+	if err == nil {
+		t.Errorf("Voice create call with bad SIP URI should fail")
+	}
+
+	_, _, err = client.CreateCall(CreateCallOpts{From: from, AnswerUrl: answer})
+	if err == nil {
+		t.Errorf("Voice create call with no endpoint should fail")
+	}
+}
+
+func TestVoiceMakeCallAllOptions(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	httpmock.RegisterResponder("POST", "https://api.nexmo.com/v1/calls/",
+		func(req *http.Request) (*http.Response, error) {
+			data, _ := ioutil.ReadAll(req.Body)
+			body = string(data)
+			resp := httpmock.NewStringResponse(201, `
+{
+  "uuid": "63f61863-4a51-4f6b-86e1-46edebcf9356",
+  "status": "started",
+  "direction": "outbound",
+  "conversation_uuid": "CON-f972836a-550f-45fa-956c-12a2ab5b7d22"
+}
+	`,
+			)
+
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		},
+	)
+
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	to := CallTo{Type: "phone", Number: "447770007788"}
+	amd := &AdvancedMachineDetection{Mode: "detect_beep", BeepTimeout: 60}
+	opts := CreateCallOpts{
+		RandomFromNumber:         true,
+		To:                       to,
+		AnswerUrl:                []string{"https://example.com/answer"},
+		AnswerMethod:             "POST",
+		FallbackUrl:              []string{"https://example.com/fallback"},
+		AdvancedMachineDetection: amd,
+	}
+
+	_, _, err := client.CreateCall(opts)
+	if err != nil {
+		t.Errorf("Voice create call with all options failed: %s", err)
+	}
+
+	expected := `{"answer_url":["https://example.com/answer"],"answer_method":"POST","fallback_url":["https://example.com/fallback"],"to":[{"type":"phone","number":"447770007788"}],"random_from_number":true,"advanced_machine_detection":{"behavior":"continue","mode":"detect_beep","beep_timeout":60}}`
+	if strings.TrimSpace(body) != expected {
+		t.Errorf("Unexpected JSON format for: create call with all options: %s", body)
+	}
+}
+
+func TestVoiceMakeCallInvalidOptions(t *testing.T) {
+	auth, _ := CreateAuthFromAppPrivateKey("00001111-aaaa-bbbb-cccc-0123456789abcd", []byte("imagine this is a private key"))
+	client := NewVoiceClient(auth)
+
+	from := CallFrom{Type: "phone", Number: "447770007777"}
+	to := CallTo{Type: "phone", Number: "447770007788"}
+	answer := []string{"https://example.com/answer"}
+
+	invalid := map[string]CreateCallOpts{
+		"from and random":    {From: from, RandomFromNumber: true, To: to, AnswerUrl: answer},
+		"no from":            {To: to, AnswerUrl: answer},
+		"answer method":      {From: from, To: to, AnswerUrl: answer, AnswerMethod: "PUT"},
+		"event method":       {From: from, To: to, AnswerUrl: answer, EventUrl: answer, EventMethod: "get"},
+		"both detections":    {From: from, To: to, AnswerUrl: answer, MachineDetection: "hangup", AdvancedMachineDetection: &AdvancedMachineDetection{}},
+		"beep timeout":       {From: from, To: to, AnswerUrl: answer, AdvancedMachineDetection: &AdvancedMachineDetection{BeepTimeout: 10}},
+		"detection mode":     {From: from, To: to, AnswerUrl: answer, AdvancedMachineDetection: &AdvancedMachineDetection{Mode: "beep"}},
+		"machine detection":  {From: from, To: to, AnswerUrl: answer, MachineDetection: "voicemail"},
+		"ringing timer":      {From: from, To: to, AnswerUrl: answer, RingingTimer: 121},
+		"length timer range": {From: from, To: to, AnswerUrl: answer, LengthTimer: 7201},
+	}
+
+	for name, opts := range invalid {
+		_, _, err := client.CreateCall(opts)
+		if err == nil {
+			t.Errorf("Voice create call should fail for invalid %s", name)
+		}
+	}
+}
+
 func TestVoiceTransferCallWithAnswerUrl(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -0,0 +1,173 @@
+package voicewebhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+)
+
+// AnswerRequest is sent to the answer URL when a call needs an NCCO. For
+// inbound calls To is your Vonage number, for outbound calls it is the
+// number that was called
+type AnswerRequest struct {
+	To               string `json:"to"`
+	From             string `json:"from"`
+	Uuid             string `json:"uuid"`
+	ConversationUuid string `json:"conversation_uuid"`
+	RegionUrl        string `json:"region_url,omitempty"`
+	CustomData       string `json:"custom_data,omitempty"`
+}
+
+// ParseAnswerRequest reads the answer request from the query string for
+// GET requests, or from the JSON body when the answer method is POST
+func ParseAnswerRequest(r *http.Request) (AnswerRequest, error) {
+	var answer AnswerRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		answer.To = q.Get("to")
+		answer.From = q.Get("from")
+		answer.Uuid = q.Get("uuid")
+		answer.ConversationUuid = q.Get("conversation_uuid")
+		answer.RegionUrl = q.Get("region_url")
+		answer.CustomData = q.Get("custom_data")
+	} else {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return answer, err
+		}
+		if err := json.Unmarshal(body, &answer); err != nil {
+			return answer, err
+		}
+	}
+
+	if answer.Uuid == "" {
+		return answer, errors.New("Answer request has no uuid")
+	}
+	return answer, nil
+}
+
+// AnswerFunc builds the NCCO for a call. Returning an error responds with
+// a server error so that Vonage tries the fallback answer URL
+type AnswerFunc func(AnswerRequest) (ncco.Ncco, error)
+
+type answerHandler struct {
+	fn AnswerFunc
+}
+
+// AnswerHandler serves the NCCO built by fn at your answer URL
+func AnswerHandler(fn AnswerFunc) http.Handler {
+	return answerHandler{fn: fn}
+}
+
+func (h answerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	answer, err := ParseAnswerRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.fn(answer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeNcco(w, n)
+}
+
+// EventFunc is called with each event of the type it was registered for.
+// Callbacks for input events, and for events sent to the eventUrl of a
+// notify action, can return an NCCO to continue the call; others return
+// an empty ncco.Ncco
+type EventFunc func(Event) (ncco.Ncco, error)
+
+// Dispatcher routes events to the callbacks registered for their type, it
+// is an http.Handler so it can serve your event URL directly
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventFunc
+	fallback EventFunc
+}
+
+// NewDispatcher creates a Dispatcher with no callbacks registered
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: map[string][]EventFunc{}}
+}
+
+// Handle registers fn for events where EventType() is eventType, such as
+// "answered", "completed" or "input". Callbacks run in the order they were
+// registered and the first non-empty NCCO is returned
+func (d *Dispatcher) Handle(eventType string, fn EventFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventType] = append(d.handlers[eventType], fn)
+}
+
+// HandleDefault registers fn for events that have no other callback
+func (d *Dispatcher) HandleDefault(fn EventFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = fn
+}
+
+// Dispatch runs the callbacks registered for the event
+func (d *Dispatcher) Dispatch(event Event) (ncco.Ncco, error) {
+	d.mu.RLock()
+	handlers := d.handlers[event.EventType()]
+	if len(handlers) == 0 && d.fallback != nil {
+		handlers = []EventFunc{d.fallback}
+	}
+	d.mu.RUnlock()
+
+	var result ncco.Ncco
+	for _, fn := range handlers {
+		n, err := fn(event)
+		if err != nil {
+			return ncco.Ncco{}, err
+		}
+		if len(result.GetActions()) == 0 {
+			result = n
+		}
+	}
+	return result, nil
+}
+
+// ServeHTTP parses the event from the request body and dispatches it
+func (d *Dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := ParseEvent(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := d.Dispatch(event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(n.GetActions()) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeNcco(w, n)
+}
+
+func writeNcco(w http.ResponseWriter, n ncco.Ncco) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,111 @@
+package voicewebhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+)
+
+func TestAnswerHandlerGet(t *testing.T) {
+	var received AnswerRequest
+	handler := AnswerHandler(func(r AnswerRequest) (ncco.Ncco, error) {
+		received = r
+		n := ncco.Ncco{}
+		n.AddAction(ncco.TalkAction{Text: "Hello"})
+		return n, nil
+	})
+
+	req := httptest.NewRequest("GET", "/answer?to=447700900000&from=447700900001&uuid=abc&conversation_uuid=CON-1", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+	if received.From != "447700900001" || received.ConversationUuid != "CON-1" {
+		t.Errorf("Unexpected answer request: %+v", received)
+	}
+	if rec.Body.String() != `[{"action":"talk","text":"Hello","bargeIn":false,"loop":1}]` {
+		t.Errorf("Unexpected JSON format for: answer NCCO: %s", rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestAnswerHandlerPost(t *testing.T) {
+	handler := AnswerHandler(func(r AnswerRequest) (ncco.Ncco, error) {
+		if r.To != "447700900000" {
+			return ncco.Ncco{}, errors.New("wrong number")
+		}
+		return ncco.Ncco{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/answer", strings.NewReader(`{"to":"447700900000","uuid":"abc"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Unexpected status: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/answer", strings.NewReader(`{"to":"447700900009","uuid":"abc"}`)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Answer func errors should give a server error, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/answer", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Answer request without a uuid should be rejected, got %d", rec.Code)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	d := NewDispatcher()
+	var completed CompletedEvent
+	var others []string
+
+	d.Handle("completed", func(e Event) (ncco.Ncco, error) {
+		completed = e.(CompletedEvent)
+		return ncco.Ncco{}, nil
+	})
+	d.Handle("input", func(e Event) (ncco.Ncco, error) {
+		n := ncco.Ncco{}
+		n.AddAction(ncco.TalkAction{Text: "You pressed " + e.(InputEvent).Dtmf.Digits})
+		return n, nil
+	})
+	d.HandleDefault(func(e Event) (ncco.Ncco, error) {
+		others = append(others, e.EventType())
+		return ncco.Ncco{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("POST", "/event", strings.NewReader(`{"status":"completed","uuid":"abc","duration":"3"}`)))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("Unexpected response to completed event: %d %s", rec.Code, rec.Body.String())
+	}
+	if completed.Duration != 3 {
+		t.Errorf("Completed callback not called: %+v", completed)
+	}
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("POST", "/event", strings.NewReader(`{"uuid":"abc","dtmf":{"digits":"42","timed_out":true}}`)))
+	if rec.Body.String() != `[{"action":"talk","text":"You pressed 42","bargeIn":false,"loop":1}]` {
+		t.Errorf("Unexpected JSON format for: input event response: %s", rec.Body.String())
+	}
+
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(`{"status":"ringing","uuid":"abc"}`)))
+	if len(others) != 1 || others[0] != "ringing" {
+		t.Errorf("Default callback not called: %v", others)
+	}
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest("POST", "/event", strings.NewReader(`nope`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Invalid event should be rejected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,313 @@
+package voicewebhook
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vonage/vonage-go-sdk/internal/voice"
+	"github.com/vonage/vonage-go-sdk/ncco"
+)
+
+// callStatusRank orders the call statuses so that events arriving out of
+// order never move a call backwards. All the final statuses share a rank,
+// once a call has ended its status doesn't change
+var callStatusRank = map[string]int{
+	"started":    1,
+	"ringing":    2,
+	"answered":   3,
+	"completed":  4,
+	"busy":       4,
+	"cancelled":  4,
+	"failed":     4,
+	"rejected":   4,
+	"timeout":    4,
+	"unanswered": 4,
+}
+
+// CallState is what the Tracker knows about one call leg
+type CallState struct {
+	Uuid             string
+	ConversationUuid string
+	Status           string
+	Direction        string
+	To               string
+	From             string
+	// AnsweredBy is "machine" or "human" when machine detection is enabled
+	AnsweredBy string
+	StartTime  time.Time
+	EndTime    time.Time
+	Duration   int
+	Rate       string
+	Price      string
+	Network    string
+	Detail     string
+	// UpdatedAt is the timestamp of the event that set the status
+	UpdatedAt time.Time
+	// TransferredAt is the timestamp of the last transfer event
+	TransferredAt time.Time
+}
+
+// Ended is true once the call has a final status
+func (s CallState) Ended() bool {
+	return callStatusRank[s.Status] == callStatusRank["completed"]
+}
+
+// Transition is sent to subscribers when a call leg changes status.
+// From is empty the first time the Tracker sees the call
+type Transition struct {
+	From string
+	To   string
+	Call CallState
+}
+
+// CallStore persists call state for a Tracker. Implementations must be
+// safe to use from several goroutines
+type CallStore interface {
+	Get(uuid string) (CallState, bool, error)
+	Put(state CallState) error
+	Conversation(conversationUuid string) ([]CallState, error)
+}
+
+// MemoryStore is a CallStore that keeps calls in a map
+type MemoryStore struct {
+	mu    sync.RWMutex
+	calls map[string]CallState
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{calls: map[string]CallState{}}
+}
+
+// Get returns the call with this uuid, if there is one
+func (s *MemoryStore) Get(uuid string) (CallState, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.calls[uuid]
+	return state, ok, nil
+}
+
+// Put saves the call, replacing any earlier state
+func (s *MemoryStore) Put(state CallState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[state.Uuid] = state
+	return nil
+}
+
+// Conversation returns all the call legs in the conversation
+func (s *MemoryStore) Conversation(conversationUuid string) ([]CallState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	legs := []CallState{}
+	for _, state := range s.calls {
+		if state.ConversationUuid == conversationUuid {
+			legs = append(legs, state)
+		}
+	}
+	return legs, nil
+}
+
+// Tracker follows each call leg through its statuses, using the voice
+// events and GetCall responses it is given
+type Tracker struct {
+	mu          sync.Mutex
+	store       CallStore
+	subscribers []subscriber
+	nextID      int
+}
+
+// subscriber is a function passed to Subscribe, kept in the order they
+// subscribed
+type subscriber struct {
+	id int
+	fn func(Transition)
+}
+
+// NewTracker creates a Tracker that keeps its state in store
+func NewTracker(store CallStore) *Tracker {
+	return &Tracker{store: store}
+}
+
+// Subscribe calls fn for every status change, call the returned function
+// to stop. Subscribers are called in the goroutine that handled the event
+func (t *Tracker) Subscribe(fn func(Transition)) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id := t.nextID
+	t.nextID++
+	t.subscribers = append(t.subscribers, subscriber{id: id, fn: fn})
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for i, s := range t.subscribers {
+			if s.id == id {
+				// copy so that a list already handed out isn't changed
+				t.subscribers = append(t.subscribers[:i:i], t.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Register adds callbacks to the Dispatcher so that every call status
+// event and transfer event is tracked
+func (t *Tracker) Register(d *Dispatcher) {
+	track := func(e Event) (ncco.Ncco, error) {
+		return ncco.Ncco{}, t.HandleEvent(e)
+	}
+	for status := range callStatusRank {
+		d.Handle(status, track)
+	}
+	d.Handle("machine", track)
+	d.Handle("human", track)
+	d.Handle("transfer", track)
+}
+
+// HandleEvent updates the call leg the event is for. Events other than
+// call status and transfer events are ignored
+func (t *Tracker) HandleEvent(e Event) error {
+	switch event := e.(type) {
+	case CallEvent:
+		return t.update(event, func(s *CallState) {})
+	case AnsweredEvent:
+		return t.update(event.CallEvent, func(s *CallState) {
+			setTime(&s.StartTime, event.StartTime)
+			setString(&s.Rate, event.Rate)
+			setString(&s.Network, event.Network)
+		})
+	case MachineEvent:
+		return t.update(event.CallEvent, func(s *CallState) {
+			s.AnsweredBy = event.Status
+		})
+	case CompletedEvent:
+		return t.update(event.CallEvent, func(s *CallState) {
+			setTime(&s.StartTime, event.StartTime)
+			setTime(&s.EndTime, event.EndTime)
+			if event.Duration != 0 {
+				s.Duration = event.Duration
+			}
+			setString(&s.Rate, event.Rate)
+			setString(&s.Price, event.Price)
+			setString(&s.Network, event.Network)
+		})
+	case UnsuccessfulEvent:
+		return t.update(event.CallEvent, func(s *CallState) {
+			setString(&s.Detail, event.Detail)
+		})
+	case TransferEvent:
+		return t.update(CallEvent{Uuid: event.Uuid, Timestamp: event.Timestamp}, func(s *CallState) {
+			if !event.Timestamp.Before(s.TransferredAt) {
+				s.ConversationUuid = event.ConversationUuidTo
+				s.TransferredAt = event.Timestamp
+			}
+		})
+	}
+	return nil
+}
+
+// HandleCallDetail updates the call leg from a GetCall response, or from
+// one of the calls returned by GetCalls
+func (t *Tracker) HandleCallDetail(call voice.GetCallResponse) error {
+	return t.update(CallEvent{
+		Uuid:             call.Uuid,
+		ConversationUuid: call.ConversationUuid,
+		Status:           call.Status,
+		Direction:        string(call.Direction),
+		To:               call.To.Number,
+		From:             call.From.Number,
+	}, func(s *CallState) {
+		setTime(&s.StartTime, parseCallTime(call.StartTime))
+		setTime(&s.EndTime, parseCallTime(call.EndTime))
+		if duration, err := strconv.Atoi(call.Duration); err == nil {
+			s.Duration = duration
+		}
+		setString(&s.Rate, call.Rate)
+		setString(&s.Price, call.Price)
+		setString(&s.Network, call.Network)
+	})
+}
+
+// Call returns the state of a call leg
+func (t *Tracker) Call(uuid string) (CallState, bool, error) {
+	return t.store.Get(uuid)
+}
+
+// Conversation returns the state of every call leg in a conversation
+func (t *Tracker) Conversation(conversationUuid string) ([]CallState, error) {
+	return t.store.Conversation(conversationUuid)
+}
+
+// update merges the event into the stored state. Details are always
+// kept, but the status only moves forward, and the conversation only
+// changes with a transfer
+func (t *Tracker) update(event CallEvent, details func(*CallState)) error {
+	if event.Uuid == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	state, found, err := t.store.Get(event.Uuid)
+	if err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	if !found {
+		state.Uuid = event.Uuid
+	}
+	// an event from behind the current status, or sent before the last
+	// transfer, would put the leg back in its old conversation
+	if callStatusRank[event.Status] >= callStatusRank[state.Status] && !event.Timestamp.Before(state.TransferredAt) {
+		setString(&state.ConversationUuid, event.ConversationUuid)
+	}
+	setString(&state.Direction, event.Direction)
+	setString(&state.To, event.To)
+	setString(&state.From, event.From)
+	details(&state)
+
+	previous := state.Status
+	rank, known := callStatusRank[event.Status]
+	changed := known && rank > callStatusRank[previous]
+	if changed {
+		state.Status = event.Status
+		state.UpdatedAt = event.Timestamp
+	}
+
+	if err := t.store.Put(state); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	subscribers := t.subscribers
+	t.mu.Unlock()
+
+	if changed {
+		for _, s := range subscribers {
+			s.fn(Transition{From: previous, To: state.Status, Call: state})
+		}
+	}
+	return nil
+}
+
+func setString(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+func setTime(field *time.Time, value time.Time) {
+	if !value.IsZero() {
+		*field = value
+	}
+}
+
+// parseCallTime reads the times in GetCall responses, which are
+// documented as "2020-01-01 12:00:00" but sometimes arrive as RFC 3339
+func parseCallTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
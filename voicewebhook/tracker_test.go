@@ -0,0 +1,135 @@
+package voicewebhook
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vonage/vonage-go-sdk/internal/voice"
+)
+
+func TestTrackerCallFlow(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore())
+	transitions := []string{}
+	tracker.Subscribe(func(tr Transition) {
+		transitions = append(transitions, tr.From+">"+tr.To)
+	})
+
+	d := NewDispatcher()
+	tracker.Register(d)
+	for _, body := range []string{
+		`{"status":"started","uuid":"leg-1","conversation_uuid":"CON-1","direction":"outbound","to":"447700900000","from":"447700900001","timestamp":"2020-03-10T15:10:00.000Z"}`,
+		`{"status":"ringing","uuid":"leg-1","conversation_uuid":"CON-1","timestamp":"2020-03-10T15:10:01.000Z"}`,
+		`{"status":"answered","uuid":"leg-1","conversation_uuid":"CON-1","network":"23410","timestamp":"2020-03-10T15:10:05.000Z"}`,
+		`{"status":"human","uuid":"leg-1","conversation_uuid":"CON-1","timestamp":"2020-03-10T15:10:06.000Z"}`,
+		`{"status":"completed","uuid":"leg-1","conversation_uuid":"CON-1","duration":"25","price":"0.004","timestamp":"2020-03-10T15:10:30.000Z"}`,
+	} {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+	}
+
+	if strings.Join(transitions, ",") != ">started,started>ringing,ringing>answered,answered>completed" {
+		t.Errorf("Unexpected transitions: %v", transitions)
+	}
+
+	call, found, _ := tracker.Call("leg-1")
+	if !found || !call.Ended() {
+		t.Fatalf("Call should be tracked and ended: %+v", call)
+	}
+	if call.AnsweredBy != "human" || call.Duration != 25 || call.Network != "23410" || call.To != "447700900000" {
+		t.Errorf("Unexpected call state: %+v", call)
+	}
+}
+
+func TestTrackerOutOfOrder(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore())
+	count := 0
+	unsubscribe := tracker.Subscribe(func(tr Transition) { count++ })
+
+	events := []string{
+		`{"status":"completed","uuid":"leg-1","conversation_uuid":"CON-1","duration":"10"}`,
+		`{"status":"answered","uuid":"leg-1","conversation_uuid":"CON-1","network":"23410"}`,
+		`{"status":"ringing","uuid":"leg-1","conversation_uuid":"CON-1","from":"447700900001"}`,
+	}
+	for _, body := range events {
+		event, _ := ParseEvent([]byte(body))
+		if err := tracker.HandleEvent(event); err != nil {
+			t.Fatalf("Event should be tracked: %s", err)
+		}
+	}
+
+	call, _, _ := tracker.Call("leg-1")
+	if call.Status != "completed" || call.From != "447700900001" || call.Network != "23410" {
+		t.Errorf("Late events should add details without changing the status: %+v", call)
+	}
+	if count != 1 {
+		t.Errorf("Expected one transition, got %d", count)
+	}
+
+	unsubscribe()
+	event, _ := ParseEvent([]byte(`{"status":"started","uuid":"leg-2","conversation_uuid":"CON-1"}`))
+	tracker.HandleEvent(event)
+	if count != 1 {
+		t.Errorf("Unsubscribed function should not be called")
+	}
+}
+
+func TestTrackerConversationAndCallDetail(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore())
+
+	tracker.HandleCallDetail(voice.GetCallResponse{
+		Uuid:             "leg-1",
+		ConversationUuid: "CON-1",
+		Status:           "completed",
+		Direction:        "inbound",
+		To:               voice.To{Type: "phone", Number: "447700900000"},
+		Duration:         "60",
+		StartTime:        "2020-01-01 12:00:00",
+	})
+	event, _ := ParseEvent([]byte(`{"status":"answered","uuid":"leg-2","conversation_uuid":"CON-1"}`))
+	tracker.HandleEvent(event)
+	event, _ = ParseEvent([]byte(`{"uuid":"leg-2","conversation_uuid_from":"CON-1","conversation_uuid_to":"CON-2"}`))
+	tracker.HandleEvent(event)
+
+	legs, _ := tracker.Conversation("CON-1")
+	if len(legs) != 1 || legs[0].Uuid != "leg-1" {
+		t.Errorf("Unexpected legs in CON-1: %+v", legs)
+	}
+	if legs[0].Duration != 60 || legs[0].StartTime.Hour() != 12 || legs[0].To != "447700900000" {
+		t.Errorf("Unexpected state from call detail: %+v", legs[0])
+	}
+
+	legs, _ = tracker.Conversation("CON-2")
+	if len(legs) != 1 || legs[0].Status != "answered" {
+		t.Errorf("Transferred leg should be in CON-2: %+v", legs)
+	}
+}
+
+func TestTrackerLateEventAfterTransfer(t *testing.T) {
+	tracker := NewTracker(NewMemoryStore())
+	for _, body := range []string{
+		`{"status":"answered","uuid":"leg-1","conversation_uuid":"CON-1","timestamp":"2020-01-01T12:00:05.000Z"}`,
+		`{"uuid":"leg-1","conversation_uuid_from":"CON-1","conversation_uuid_to":"CON-2","timestamp":"2020-01-01T12:00:10.000Z"}`,
+		`{"status":"ringing","uuid":"leg-1","conversation_uuid":"CON-1","timestamp":"2020-01-01T12:00:02.000Z"}`,
+		`{"status":"answered","uuid":"leg-1","conversation_uuid":"CON-1","timestamp":"2020-01-01T12:00:05.000Z"}`,
+	} {
+		event, err := ParseEvent([]byte(body))
+		if err != nil {
+			t.Fatalf("Parsing event failed: %s", err)
+		}
+		tracker.HandleEvent(event)
+	}
+
+	if legs, _ := tracker.Conversation("CON-1"); len(legs) != 0 {
+		t.Errorf("Late events should not move the leg back to CON-1: %+v", legs)
+	}
+	legs, _ := tracker.Conversation("CON-2")
+	if len(legs) != 1 || legs[0].Status != "answered" {
+		t.Errorf("Transferred leg should stay in CON-2: %+v", legs)
+	}
+
+	event, _ := ParseEvent([]byte(`{"status":"completed","uuid":"leg-1","conversation_uuid":"CON-2","timestamp":"2020-01-01T12:01:00.000Z"}`))
+	tracker.HandleEvent(event)
+	if state, _, _ := tracker.Call("leg-1"); state.Status != "completed" || state.ConversationUuid != "CON-2" {
+		t.Errorf("Unexpected state after the call ends: %+v", state)
+	}
+}
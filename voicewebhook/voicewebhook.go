@@ -0,0 +1,263 @@
+// Package voicewebhook has types for the webhooks that the Voice API sends
+// to your application: the answer request when a call needs an NCCO, and the
+// events sent to the event URL as the call progresses
+package voicewebhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vonage/vonage-go-sdk/ncco"
+)
+
+// Event is implemented by all the event types, EventType is the call
+// status for status events, or one of "record", "transcription",
+// "input", "transfer", "error" or "unknown"
+type Event interface {
+	EventType() string
+}
+
+// CallEvent holds the fields that all call status events have. The
+// started and ringing events are sent as a CallEvent
+type CallEvent struct {
+	Uuid             string    `json:"uuid"`
+	ConversationUuid string    `json:"conversation_uuid"`
+	Status           string    `json:"status"`
+	Direction        string    `json:"direction"`
+	To               string    `json:"to"`
+	From             string    `json:"from"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// EventType for a call status event is the status
+func (e CallEvent) EventType() string {
+	return e.Status
+}
+
+// AnsweredEvent is sent when the call is answered
+type AnsweredEvent struct {
+	CallEvent
+	StartTime time.Time `json:"start_time"`
+	Rate      string    `json:"rate,omitempty"`
+	Network   string    `json:"network,omitempty"`
+}
+
+// MachineEvent is sent with status "machine" or "human" when machine
+// detection is enabled. SubState is "beep_start" or "beep_timeout" when
+// advanced machine detection is listening for the voicemail beep
+type MachineEvent struct {
+	CallEvent
+	CallUuid string `json:"call_uuid,omitempty"`
+	SubState string `json:"sub_state,omitempty"`
+}
+
+// CompletedEvent is sent when the call ends, with the duration in seconds
+// and the cost
+type CompletedEvent struct {
+	CallEvent
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	Duration       int       `json:"duration,string"`
+	Rate           string    `json:"rate,omitempty"`
+	Price          string    `json:"price,omitempty"`
+	Network        string    `json:"network,omitempty"`
+	DisconnectedBy string    `json:"disconnected_by,omitempty"`
+}
+
+// UnsuccessfulEvent is sent when the call did not connect, with status
+// busy, cancelled, failed, rejected, timeout or unanswered
+type UnsuccessfulEvent struct {
+	CallEvent
+	Detail string `json:"detail,omitempty"`
+}
+
+// RecordEvent is sent when a recording is ready to download
+type RecordEvent struct {
+	StartTime        time.Time `json:"start_time"`
+	EndTime          time.Time `json:"end_time"`
+	RecordingUrl     string    `json:"recording_url"`
+	RecordingUuid    string    `json:"recording_uuid"`
+	Size             int       `json:"size"`
+	ConversationUuid string    `json:"conversation_uuid"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// EventType for a record event is "record"
+func (e RecordEvent) EventType() string {
+	return "record"
+}
+
+// TranscriptionEvent is sent when the transcription of a recording is
+// ready to download from TranscriptionUrl
+type TranscriptionEvent struct {
+	ConversationUuid string    `json:"conversation_uuid"`
+	Type             string    `json:"type"`
+	RecordingUuid    string    `json:"recording_uuid"`
+	Status           string    `json:"status"`
+	TranscriptionUrl string    `json:"transcription_url"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// EventType for a transcription event is "transcription"
+func (e TranscriptionEvent) EventType() string {
+	return "transcription"
+}
+
+// DtmfResult holds the digits the caller pressed
+type DtmfResult = ncco.DtmfResult
+
+// SpeechResult is one possible transcription of what the caller said
+type SpeechResult = ncco.SpeechTranscript
+
+// SpeechInput holds the speech recognition results, most likely first
+type SpeechInput = ncco.SpeechResult
+
+// InputEvent is sent to the eventUrl of an input action. Respond with an
+// NCCO to continue the call
+type InputEvent struct {
+	Uuid             string       `json:"uuid"`
+	ConversationUuid string       `json:"conversation_uuid"`
+	To               string       `json:"to"`
+	From             string       `json:"from"`
+	Timestamp        time.Time    `json:"timestamp"`
+	Dtmf             *DtmfResult  `json:"dtmf,omitempty"`
+	Speech           *SpeechInput `json:"speech,omitempty"`
+}
+
+// Result returns what the input action collected as an ncco.InputResult
+func (e InputEvent) Result() ncco.InputResult {
+	return ncco.InputResult{Dtmf: e.Dtmf, Speech: e.Speech}
+}
+
+// Digits returns the DTMF digits, or "" if there weren't any
+func (e InputEvent) Digits() string {
+	return e.Result().Digits()
+}
+
+// BestTranscript returns the speech result with the highest confidence
+func (e InputEvent) BestTranscript() (SpeechResult, bool) {
+	return e.Result().BestTranscript()
+}
+
+// TimedOut is true when neither digits nor speech were collected before
+// the input timed out
+func (e InputEvent) TimedOut() bool {
+	return e.Result().TimedOut()
+}
+
+// EventType for an input event is "input"
+func (e InputEvent) EventType() string {
+	return "input"
+}
+
+// TransferEvent is sent when a call leg moves to another conversation
+type TransferEvent struct {
+	Uuid                 string    `json:"uuid"`
+	ConversationUuidFrom string    `json:"conversation_uuid_from"`
+	ConversationUuidTo   string    `json:"conversation_uuid_to"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// EventType for a transfer event is "transfer"
+func (e TransferEvent) EventType() string {
+	return "transfer"
+}
+
+// ErrorEvent is sent when Vonage could not carry out the NCCO, such as
+// when the answer URL returns something that isn't a valid NCCO
+type ErrorEvent struct {
+	Reason           string    `json:"reason"`
+	ConversationUuid string    `json:"conversation_uuid"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// EventType for an error event is "error"
+func (e ErrorEvent) EventType() string {
+	return "error"
+}
+
+// UnknownEvent holds any event this package doesn't recognise, including
+// the payloads sent by the notify action
+type UnknownEvent struct {
+	Data map[string]interface{}
+}
+
+// EventType for an unrecognised event is "unknown"
+func (e UnknownEvent) EventType() string {
+	return "unknown"
+}
+
+// eventProbe has just enough fields to tell the events apart
+type eventProbe struct {
+	Status             string          `json:"status"`
+	RecordingUrl       string          `json:"recording_url"`
+	Dtmf               json.RawMessage `json:"dtmf"`
+	Speech             json.RawMessage `json:"speech"`
+	ConversationUuidTo string          `json:"conversation_uuid_to"`
+	Reason             string          `json:"reason"`
+	TranscriptionUrl   string          `json:"transcription_url"`
+}
+
+// ParseEvent reads the body of an event webhook into the matching event type
+func ParseEvent(data []byte) (Event, error) {
+	var probe eventProbe
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	var err error
+	switch {
+	case probe.TranscriptionUrl != "":
+		// transcription events have a status too, so check for them first
+		var e TranscriptionEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Status == "answered":
+		var e AnsweredEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Status == "machine" || probe.Status == "human":
+		var e MachineEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Status == "completed":
+		var e CompletedEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Status == "busy" || probe.Status == "cancelled" || probe.Status == "failed" ||
+		probe.Status == "rejected" || probe.Status == "timeout" || probe.Status == "unanswered":
+		var e UnsuccessfulEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Status != "":
+		var e CallEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.RecordingUrl != "" && probe.Dtmf == nil && probe.Speech == nil:
+		var e RecordEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Dtmf != nil || probe.Speech != nil:
+		var e InputEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.ConversationUuidTo != "":
+		var e TransferEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	case probe.Reason != "":
+		var e ErrorEvent
+		err = json.Unmarshal(data, &e)
+		event = e
+	default:
+		var e UnknownEvent
+		err = json.Unmarshal(data, &e.Data)
+		event = e
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,90 @@
+package voicewebhook
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseEventCompleted(t *testing.T) {
+	body := `{"end_time":"2020-03-10T15:10:34.000Z","uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab","network":"23410","duration":"25","start_time":"2020-03-10T15:10:09.000Z","rate":"0.01000000","price":"0.00416667","from":"447700900001","to":"447700900000","conversation_uuid":"CON-aaaaaaaa-bbbb-cccc-dddd-0123456789ab","status":"completed","direction":"outbound","disconnected_by":"user","timestamp":"2020-03-10T15:10:34.120Z"}`
+
+	event, err := ParseEvent([]byte(body))
+	if err != nil {
+		t.Fatalf("Completed event should parse: %s", err)
+	}
+
+	completed, ok := event.(CompletedEvent)
+	if !ok {
+		t.Fatalf("Expected a CompletedEvent, got %T", event)
+	}
+	if completed.EventType() != "completed" || completed.Duration != 25 || completed.Price != "0.00416667" {
+		t.Errorf("Unexpected completed event: %+v", completed)
+	}
+	if !completed.EndTime.Equal(time.Date(2020, 3, 10, 15, 10, 34, 0, time.UTC)) {
+		t.Errorf("Unexpected end time: %s", completed.EndTime)
+	}
+	if completed.Uuid != "aaaaaaaa-bbbb-cccc-dddd-0123456789ab" || completed.DisconnectedBy != "user" {
+		t.Errorf("Unexpected completed event: %+v", completed)
+	}
+}
+
+func TestParseEventStatuses(t *testing.T) {
+	cases := map[string]string{
+		`{"status":"started","uuid":"abc","timestamp":"2020-03-10T15:10:00.000Z"}`:                               "voicewebhook.CallEvent",
+		`{"status":"ringing","uuid":"abc"}`:                                                                      "voicewebhook.CallEvent",
+		`{"status":"answered","uuid":"abc","start_time":null}`:                                                   "voicewebhook.AnsweredEvent",
+		`{"status":"machine","uuid":"abc","sub_state":"beep_start"}`:                                             "voicewebhook.MachineEvent",
+		`{"status":"human","uuid":"abc"}`:                                                                        "voicewebhook.MachineEvent",
+		`{"status":"busy","uuid":"abc","detail":"busy"}`:                                                         "voicewebhook.UnsuccessfulEvent",
+		`{"recording_url":"https://api.nexmo.com/v1/files/abc","recording_uuid":"def","size":12222}`:             "voicewebhook.RecordEvent",
+		`{"uuid":"abc","dtmf":{"digits":"1","timed_out":false}}`:                                                 "voicewebhook.InputEvent",
+		`{"uuid":"abc","conversation_uuid_from":"CON-1","conversation_uuid_to":"CON-2"}`:                         "voicewebhook.TransferEvent",
+		`{"reason":"Invalid NCCO","conversation_uuid":"CON-1"}`:                                                  "voicewebhook.ErrorEvent",
+		`{"status":"completed","type":"transcription","transcription_url":"https://api.nexmo.com/v1/files/abc"}`: "voicewebhook.TranscriptionEvent",
+		`{"my_field":"anything"}`:                                                                                "voicewebhook.UnknownEvent",
+	}
+
+	for body, expected := range cases {
+		event, err := ParseEvent([]byte(body))
+		if err != nil {
+			t.Errorf("Event %s should parse: %s", body, err)
+			continue
+		}
+		if actual := fmt.Sprintf("%T", event); actual != expected {
+			t.Errorf("Event %s parsed as %s, expected %s", body, actual, expected)
+		}
+	}
+}
+
+func TestParseEventInput(t *testing.T) {
+	body := `{"speech":{"timeout_reason":"end_on_silence_timeout","results":[{"confidence":"0.9405097","text":"sales"},{"confidence":0.7,"text":"sails"}]},"dtmf":{"digits":null,"timed_out":false},"from":"447700900001","to":"447700900000","uuid":"abc","conversation_uuid":"CON-1","timestamp":"2020-03-10T15:10:00.000Z"}`
+
+	event, err := ParseEvent([]byte(body))
+	if err != nil {
+		t.Fatalf("Input event should parse: %s", err)
+	}
+
+	input := event.(InputEvent)
+	if input.Speech == nil || len(input.Speech.Results) != 2 {
+		t.Fatalf("Expected two speech results: %+v", input)
+	}
+	if input.Speech.Results[0].Text != "sales" || input.Speech.Results[0].Confidence != 0.9405097 {
+		t.Errorf("Unexpected first speech result: %+v", input.Speech.Results[0])
+	}
+	if input.Speech.Results[1].Confidence != 0.7 {
+		t.Errorf("Unexpected second speech result: %+v", input.Speech.Results[1])
+	}
+	if input.Dtmf == nil || input.Dtmf.Digits != "" {
+		t.Errorf("Unexpected DTMF result: %+v", input.Dtmf)
+	}
+}
+
+func TestParseEventInvalid(t *testing.T) {
+	if _, err := ParseEvent([]byte(`not json`)); err == nil {
+		t.Errorf("Invalid JSON should not parse")
+	}
+	if _, err := ParseEvent([]byte(`{"status":"completed","duration":"soon"}`)); err == nil {
+		t.Errorf("Completed event with a bad duration should not parse")
+	}
+}